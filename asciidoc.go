@@ -0,0 +1,892 @@
+package showandtell
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterSlideFormat("adoc", &AsciiDocSlideParser{})
+}
+
+// AsciiDocSlideParser reads the common subset of AsciiDoc: section titles,
+// paragraphs, lists, tables, admonitions, listing, source and quote blocks
+// with callouts, images, attributes and includes relative to the slide file.
+//
+// Like with asciidoctor-reveal.js, every == section is a slide and every ===
+// section a slide in a vertical stack. The document title and everything
+// before the first section is a slide of its own. Blocks with the role notes
+// are the speaker notes:
+//
+//	== Slide
+//
+//	* a point
+//
+//	[.notes]
+//	--
+//	Remember to mention the other point.
+//	--
+//
+// Roles of blocks are classes, so [.fragment] shows a block as fragment. As
+// [[ ]] are anchors, AsciiDoc slides aren't executed as template.
+type AsciiDocSlideParser struct{}
+
+var (
+	adocHeadingRegexp   = regexp.MustCompile(`^(=+)\s+(\S.*)$`)
+	adocAttributeRegexp = regexp.MustCompile(`^:(!?[\w-]+!?):(?:\s+(.*))?$`)
+	adocDelimiterRegexp = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|\*{4,}|_{4,}|\+{4,}|/{4,}|--|\|===)$`)
+	adocShorthandRegexp = regexp.MustCompile(`[#.%][^#.%]*`)
+)
+
+// SplitSlides splits the document on its == and === sections, after
+// expanding its includes
+func (a *AsciiDocSlideParser) SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error) {
+	ctx := &SlideContext{Presentation: *pres}
+	ctx.SourceFile = slidePath
+	lines := strings.Split(strings.Replace(string(input), "\r\n", "\n", -1), "\n")
+	lines, err := expandAdocIncludes(ctx.resolvePath, lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		stacks     [][]*SlideSource
+		body       []string
+		start      = 1
+		delimiter  string
+		attributes []string
+		inHeader   = true
+	)
+	next := func(line int, horizontal bool) error {
+		if len(stacks) > 0 || hasAdocContent(body) {
+			body, notes := extractAdocNotes(body)
+			notesHTML := ""
+			if len(notes) > 0 {
+				r := newAdocRenderer(ctx)
+				if err := r.blocks(notes); err != nil {
+					return err
+				}
+				// Notes are Markdown, which keeps blocks of HTML as is
+				notesHTML = "<div>\n" + r.buf.String() + "</div>\n"
+			}
+			if len(stacks) == 0 {
+				stacks = append(stacks, nil)
+			}
+			stacks[len(stacks)-1] = append(stacks[len(stacks)-1], &SlideSource{
+				Body:       []byte(strings.Join(body, "\n")),
+				Notes:      []byte(notesHTML),
+				Line:       start,
+				NoTemplate: true,
+			})
+		}
+		if horizontal || len(stacks) == 0 {
+			stacks = append(stacks, nil)
+		}
+		// Attributes of the header apply to all slides
+		body = append([]string{}, attributes...)
+		start = line
+		return nil
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		switch {
+		case delimiter != "":
+			if trimmed == delimiter {
+				delimiter = ""
+			}
+		case adocDelimiterRegexp.MatchString(trimmed):
+			delimiter = trimmed
+		case adocAttributeRegexp.MatchString(trimmed) && inHeader:
+			attributes = append(attributes, trimmed)
+		default:
+			if m := adocHeadingRegexp.FindStringSubmatch(trimmed); m != nil && len(m[1]) <= 3 && len(m[1]) > 1 {
+				inHeader = false
+				if err := next(i+1, len(m[1]) == 2); err != nil {
+					return nil, err
+				}
+			}
+		}
+		body = append(body, line)
+	}
+	if err := next(0, false); err != nil {
+		return nil, err
+	}
+	if len(stacks[len(stacks)-1]) == 0 {
+		stacks = stacks[:len(stacks)-1]
+	}
+	if len(stacks) == 0 {
+		return [][]*SlideSource{{{Body: []byte(strings.Join(lines, "\n")), Line: 1, NoTemplate: true}}}, nil
+	}
+	return stacks, nil
+}
+
+// hasAdocContent returns false if lines are only blank, comments or
+// attribute entries
+func hasAdocContent(lines []string) bool {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "//") && !adocAttributeRegexp.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractAdocNotes removes the blocks with the role notes from lines and
+// returns their content
+func extractAdocNotes(lines []string) (body []string, notes []string) {
+	for i := 0; i < len(lines); i++ {
+		attrs := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(attrs, "[") && strings.HasSuffix(attrs, "]") && i+1 < len(lines) &&
+			parseAdocAttributes(attrs[1:len(attrs)-1]).hasRole("notes") {
+			delimiter := strings.TrimSpace(lines[i+1])
+			if adocDelimiterRegexp.MatchString(delimiter) {
+				end := i + 2
+				for end < len(lines) && strings.TrimSpace(lines[end]) != delimiter {
+					end++
+				}
+				if len(notes) > 0 {
+					notes = append(notes, "")
+				}
+				if end > len(lines) {
+					end = len(lines)
+				}
+				notes = append(notes, lines[i+2:end]...)
+				i = end
+				continue
+			}
+		}
+		body = append(body, lines[i])
+	}
+	return body, notes
+}
+
+func (a *AsciiDocSlideParser) ParseSlide(ctx *SlideContext, input []byte) (template.HTML, error) {
+	lines := strings.Split(strings.Replace(string(input), "\r\n", "\n", -1), "\n")
+	lines, err := expandAdocIncludes(ctx.resolvePath, lines, 0)
+	if err != nil {
+		return "", err
+	}
+	lines, _ = extractAdocNotes(lines)
+	r := newAdocRenderer(ctx)
+	if err := r.blocks(r.header(lines)); err != nil {
+		return "", err
+	}
+	return template.HTML(r.buf.String()), nil
+}
+
+var adocIncludeRegexp = regexp.MustCompile(`^include::([^\[]+)\[(.*)\]$`)
+
+// expandAdocIncludes replaces include directives by the lines of the file,
+// selected by the lines or tag attribute. resolve returns the path of the
+// file, includes in included files are relative to their file.
+func expandAdocIncludes(resolve func(string) string, lines []string, depth int) ([]string, error) {
+	if depth > 8 {
+		return nil, fmt.Errorf("Includes are nested too deep")
+	}
+	var out []string
+	for _, line := range lines {
+		m := adocIncludeRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			out = append(out, line)
+			continue
+		}
+		path := resolve(m[1])
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		included := strings.Split(strings.TrimSuffix(strings.Replace(string(buf), "\r\n", "\n", -1), "\n"), "\n")
+		attrs := parseAdocAttributes(m[2])
+		if lineRanges := attrs.named["lines"]; lineRanges != "" {
+			if included, err = adocSelectLines(included, lineRanges); err != nil {
+				return nil, fmt.Errorf("include::%s: %s", m[1], err)
+			}
+		}
+		if tag := attrs.named["tag"]; tag != "" {
+			if included, err = adocTaggedLines(included, tag); err != nil {
+				return nil, fmt.Errorf("include::%s: %s", m[1], err)
+			}
+		}
+		dir := filepath.Dir(path)
+		included, err = expandAdocIncludes(func(p string) string {
+			if filepath.IsAbs(p) {
+				return p
+			}
+			return filepath.Join(dir, filepath.FromSlash(p))
+		}, included, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, included...)
+	}
+	return out, nil
+}
+
+// adocSelectLines returns the lines within ranges like "1..3;7..-1", where
+// -1 is the last line
+func adocSelectLines(lines []string, ranges string) ([]string, error) {
+	var out []string
+	for _, r := range strings.FieldsFunc(ranges, func(c rune) bool { return c == ';' || c == ',' }) {
+		parts := strings.SplitN(strings.TrimSpace(r), "..", 2)
+		start, err := strconv.Atoi(parts[0])
+		end := start
+		if err == nil && len(parts) == 2 {
+			end, err = strconv.Atoi(parts[1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid line range %q", r)
+		}
+		if end == -1 {
+			end = len(lines)
+		}
+		if start < 1 || end < start || end > len(lines) {
+			return nil, fmt.Errorf("Line range %q is out of bounds, the file has %d lines", r, len(lines))
+		}
+		out = append(out, lines[start-1:end]...)
+	}
+	return out, nil
+}
+
+// adocTaggedLines returns the lines between "tag::name[]" and "end::name[]",
+// lines with tags are left out
+func adocTaggedLines(lines []string, tag string) ([]string, error) {
+	var out []string
+	inTag, found := false, false
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "tag::"+tag+"[]"):
+			inTag, found = true, true
+		case strings.Contains(line, "end::"+tag+"[]"):
+			inTag = false
+		case inTag && !strings.Contains(line, "tag::") && !strings.Contains(line, "end::"):
+			out = append(out, line)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Tag %s not found", tag)
+	}
+	return out, nil
+}
+
+// adocAttributes are the attributes of a block like [source,go,linenums] or
+// [quote, Author] or [%header,cols="1,2"]
+type adocAttributes struct {
+	style      string
+	positional []string
+	named      map[string]string
+	roles      []string
+	options    map[string]bool
+}
+
+func parseAdocAttributes(s string) *adocAttributes {
+	attrs := &adocAttributes{named: map[string]string{}, options: map[string]bool{}}
+	var parts []string
+	inQuotes, last := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[last:])
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if eq := strings.Index(part, "="); eq > 0 && !strings.HasPrefix(part, `"`) {
+			value := strings.Trim(strings.TrimSpace(part[eq+1:]), `"`)
+			key := strings.TrimSpace(part[:eq])
+			attrs.named[key] = value
+			switch key {
+			case "role":
+				attrs.roles = append(attrs.roles, strings.Fields(value)...)
+			case "options", "opts":
+				for _, o := range strings.Split(value, ",") {
+					attrs.options[strings.TrimSpace(o)] = true
+				}
+			}
+			continue
+		}
+		part = strings.Trim(part, `"`)
+		if i == 0 {
+			// The first positional attribute is the style followed by
+			// shorthands for the ID, roles and options: style#id.role%option
+			style := part
+			if idx := strings.IndexAny(part, "#.%"); idx >= 0 {
+				style = part[:idx]
+				for _, shorthand := range adocShorthandRegexp.FindAllString(part[idx:], -1) {
+					switch shorthand[0] {
+					case '.':
+						attrs.roles = append(attrs.roles, shorthand[1:])
+					case '%':
+						attrs.options[shorthand[1:]] = true
+					}
+				}
+			}
+			attrs.style = style
+		}
+		attrs.positional = append(attrs.positional, part)
+	}
+	if len(attrs.positional) > 0 {
+		attrs.positional[0] = attrs.style
+	}
+	return attrs
+}
+
+func (a *adocAttributes) hasRole(role string) bool {
+	for _, r := range a.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *adocAttributes) positionalAt(i int) string {
+	if a == nil || i >= len(a.positional) {
+		return ""
+	}
+	return a.positional[i]
+}
+
+// adocRenderer renders AsciiDoc blocks to HTML
+type adocRenderer struct {
+	ctx        *SlideContext
+	attributes map[string]string
+	buf        *strings.Builder
+}
+
+func newAdocRenderer(ctx *SlideContext) *adocRenderer {
+	return &adocRenderer{ctx: ctx, attributes: map[string]string{}, buf: &strings.Builder{}}
+}
+
+var (
+	adocListItemRegexp    = regexp.MustCompile(`^\s*(\*+|-|\.+|\d+\.)\s+(.*)$`)
+	adocCalloutItemRegexp = regexp.MustCompile(`^<(\d+)>\s+(.*)$`)
+	adocAdmonitionRegexp  = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	adocBlockImageRegexp  = regexp.MustCompile(`^image::([^\[]+)\[(.*)\]$`)
+	adocAdmonitions       = map[string]bool{"NOTE": true, "TIP": true, "IMPORTANT": true, "WARNING": true, "CAUTION": true}
+)
+
+// header renders the document title with the author and revision lines
+// following it and returns the remaining lines
+func (r *adocRenderer) header(lines []string) []string {
+	i := 0
+	for i < len(lines) && (strings.TrimSpace(lines[i]) == "" || strings.HasPrefix(lines[i], "//") ||
+		adocAttributeRegexp.MatchString(strings.TrimSpace(lines[i]))) {
+		r.attribute(strings.TrimSpace(lines[i]))
+		i++
+	}
+	if i >= len(lines) || !strings.HasPrefix(lines[i], "= ") {
+		return lines
+	}
+	fmt.Fprintf(r.buf, "<h1>%s</h1>\n", r.inline(strings.TrimSpace(lines[i][2:])))
+	// The author line and the revision line follow the title
+	classes := []string{"author", "revision"}
+	for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "//") || r.attribute(line) || len(classes) == 0 {
+			continue
+		}
+		fmt.Fprintf(r.buf, "<p class=\"%s\">%s</p>\n", classes[0], r.inline(line))
+		classes = classes[1:]
+	}
+	return lines[i:]
+}
+
+// attribute sets the attribute of an entry like ":name: value"
+func (r *adocRenderer) attribute(line string) bool {
+	m := adocAttributeRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	if name := strings.Trim(m[1], "!"); name != m[1] {
+		delete(r.attributes, name)
+	} else {
+		r.attributes[name] = m[2]
+	}
+	return true
+}
+
+// blocks renders lines as sequence of blocks
+func (r *adocRenderer) blocks(lines []string) error {
+	var (
+		attrs *adocAttributes
+		title string
+	)
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			i++
+			continue
+		}
+
+		// Lines preceding a block
+		switch {
+		case strings.HasPrefix(line, "//") && !adocDelimiterRegexp.MatchString(line):
+			i++
+			continue
+		case r.attribute(line):
+			i++
+			continue
+		case strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]"):
+			// Anchors
+			i++
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			attrs = parseAdocAttributes(line[1 : len(line)-1])
+			i++
+			continue
+		case len(line) > 1 && line[0] == '.' && line[1] != '.' && line[1] != ' ':
+			title = line[1:]
+			i++
+			continue
+		}
+
+		if title != "" {
+			fmt.Fprintf(r.buf, "<div class=\"title\">%s</div>\n", r.inline(title))
+		}
+		var err error
+		switch m := adocHeadingRegexp.FindStringSubmatch(line); {
+		case m != nil:
+			level := len(m[1])
+			if level > 6 {
+				level = 6
+			}
+			fmt.Fprintf(r.buf, "<h%d%s>%s</h%d>\n", level, classAttr("", attrs), r.inline(m[2]), level)
+			i++
+		case adocDelimiterRegexp.MatchString(line):
+			end := i + 1
+			for end < len(lines) && strings.TrimRight(lines[end], " \t") != line {
+				end++
+			}
+			err = r.delimitedBlock(line, lines[i+1:end], attrs)
+			i = end + 1
+		case line == "'''":
+			r.buf.WriteString("<hr>\n")
+			i++
+		case line == "<<<":
+			i++
+		case adocBlockImageRegexp.MatchString(line):
+			m := adocBlockImageRegexp.FindStringSubmatch(line)
+			fmt.Fprintf(r.buf, "<p%s>%s</p>\n", classAttr("image", attrs), r.image(m[1], m[2]))
+			i++
+		case adocCalloutItemRegexp.MatchString(line):
+			r.buf.WriteString("<ol class=\"callouts\">\n")
+			for ; i < len(lines) && adocCalloutItemRegexp.MatchString(lines[i]); i++ {
+				m := adocCalloutItemRegexp.FindStringSubmatch(lines[i])
+				fmt.Fprintf(r.buf, "<li value=\"%s\">%s</li>\n", m[1], r.inline(m[2]))
+			}
+			r.buf.WriteString("</ol>\n")
+		case adocListItemRegexp.MatchString(line):
+			i = r.list(lines, i, attrs)
+		case line[0] == ' ' || line[0] == '\t':
+			var literal []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				literal = append(literal, lines[i])
+			}
+			fmt.Fprintf(r.buf, "<pre%s>%s</pre>\n", classAttr("literal", attrs), template.HTMLEscapeString(dedent(literal)))
+		default:
+			var paragraph []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !adocDelimiterRegexp.MatchString(strings.TrimSpace(lines[i])); i++ {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+			}
+			text := strings.Join(paragraph, "\n")
+			if m := adocAdmonitionRegexp.FindStringSubmatch(text); m != nil {
+				r.admonition(m[1], "<p>"+r.inline(m[2])+"</p>\n", attrs)
+			} else if style := attrs.positionalAt(0); adocAdmonitions[style] {
+				r.admonition(style, "<p>"+r.inline(text)+"</p>\n", attrs)
+			} else {
+				fmt.Fprintf(r.buf, "<p%s>%s</p>\n", classAttr("", attrs), r.inline(text))
+			}
+		}
+		if err != nil {
+			return err
+		}
+		attrs, title = nil, ""
+	}
+	return nil
+}
+
+// classAttr returns the class attribute with the roles of a block
+func classAttr(class string, attrs *adocAttributes) string {
+	classes := []string{}
+	if class != "" {
+		classes = append(classes, class)
+	}
+	if attrs != nil {
+		classes = append(classes, attrs.roles...)
+	}
+	if len(classes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` class="%s"`, template.HTMLEscapeString(strings.Join(classes, " ")))
+}
+
+func (r *adocRenderer) admonition(kind, content string, attrs *adocAttributes) {
+	fmt.Fprintf(r.buf, "<div%s>\n<p class=\"admonition-title\">%s</p>\n%s</div>\n",
+		classAttr("admonition "+strings.ToLower(kind), attrs), kind[:1]+strings.ToLower(kind[1:]), content)
+}
+
+// nested renders lines as blocks of their own
+func (r *adocRenderer) nested(lines []string) (string, error) {
+	nested := &adocRenderer{ctx: r.ctx, attributes: r.attributes, buf: &strings.Builder{}}
+	err := nested.blocks(lines)
+	return nested.buf.String(), err
+}
+
+func (r *adocRenderer) delimitedBlock(delimiter string, lines []string, attrs *adocAttributes) error {
+	style := attrs.positionalAt(0)
+	switch {
+	case strings.HasPrefix(delimiter, "////"):
+		return nil
+	case delimiter == "|===":
+		r.table(lines, attrs)
+		return nil
+	case strings.HasPrefix(delimiter, "++++"):
+		r.buf.WriteString(strings.Join(lines, "\n") + "\n")
+		return nil
+	case strings.HasPrefix(delimiter, "...."):
+		fmt.Fprintf(r.buf, "<pre%s>%s</pre>\n", classAttr("literal", attrs), template.HTMLEscapeString(strings.Join(lines, "\n")))
+		return nil
+	case strings.HasPrefix(delimiter, "----") || (delimiter == "--" && style == "source"):
+		return r.listing(lines, attrs)
+	}
+
+	content, err := r.nested(lines)
+	if err != nil {
+		return err
+	}
+	switch {
+	case adocAdmonitions[style]:
+		r.admonition(style, content, attrs)
+	case strings.HasPrefix(delimiter, "____") || style == "quote":
+		attribution := ""
+		if author := attrs.positionalAt(1); author != "" {
+			attribution = "<footer>" + r.inline(author)
+			if source := attrs.positionalAt(2); source != "" {
+				attribution += ", <cite>" + r.inline(source) + "</cite>"
+			}
+			attribution += "</footer>\n"
+		}
+		fmt.Fprintf(r.buf, "<blockquote%s>\n%s%s</blockquote>\n", classAttr("", attrs), content, attribution)
+	case strings.HasPrefix(delimiter, "===="):
+		fmt.Fprintf(r.buf, "<div%s>\n%s</div>\n", classAttr("example", attrs), content)
+	case strings.HasPrefix(delimiter, "****"):
+		fmt.Fprintf(r.buf, "<aside%s>\n%s</aside>\n", classAttr("sidebar", attrs), content)
+	default:
+		fmt.Fprintf(r.buf, "<div%s>\n%s</div>\n", classAttr("", attrs), content)
+	}
+	return nil
+}
+
+var (
+	adocCalloutRegexp       = regexp.MustCompile(`(?:\s*(?://|#|--|;;))?((?:\s*<\d+>)+)\s*$`)
+	adocCalloutNumberRegexp = regexp.MustCompile(`\d+`)
+)
+
+// listing renders a listing or source block, numbered callouts like <1> at
+// the end of lines are shown after the highlighted lines
+func (r *adocRenderer) listing(lines []string, attrs *adocAttributes) error {
+	lang := ""
+	if style := attrs.positionalAt(0); style == "source" || style == "" {
+		lang = attrs.positionalAt(1)
+	}
+	lineNumbers := attrs != nil && (attrs.options["linenums"] || attrs.positionalAt(2) == "linenums")
+
+	code := make([]string, len(lines))
+	callouts := make([][]string, len(lines))
+	for i, line := range lines {
+		if m := adocCalloutRegexp.FindStringSubmatchIndex(line); m != nil {
+			for _, n := range adocCalloutNumberRegexp.FindAllString(line[m[2]:m[3]], -1) {
+				callouts[i] = append(callouts[i], n)
+			}
+			line = line[:m[0]]
+		}
+		code[i] = line
+	}
+	out, err := highlightCode(&r.ctx.Presentation, strings.Join(code, "\n")+"\n", lang, "", 1, lineNumbers)
+	if err != nil {
+		return err
+	}
+
+	// Lines of highlighted code end with a newline and two closing spans
+	html := string(out)
+	const lineEnd = "\n</span></span>"
+	pos := 0
+	for i := range lines {
+		next := strings.Index(html[pos:], lineEnd)
+		if next < 0 {
+			break
+		}
+		pos += next
+		if len(callouts[i]) > 0 {
+			marks := ""
+			for _, n := range callouts[i] {
+				marks += fmt.Sprintf(` <b class="conum">(%s)</b>`, n)
+			}
+			html = html[:pos] + marks + html[pos:]
+			pos += len(marks)
+		}
+		pos += len(lineEnd)
+	}
+	if classes := classAttr("", attrs); classes != "" {
+		html = fmt.Sprintf("<div%s>%s</div>\n", classes, html)
+	}
+	r.buf.WriteString(html)
+	return nil
+}
+
+// table renders a table, the first line is the header if it is followed by
+// an empty line or the header option is set
+func (r *adocRenderer) table(lines []string, attrs *adocAttributes) {
+	var cells []string
+	columns := 0
+	header := attrs != nil && attrs.options["header"]
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if i == 1 && len(cells) > 0 && (attrs == nil || !attrs.options["noheader"]) {
+				header = true
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			if len(cells) > 0 {
+				cells[len(cells)-1] += "\n" + line
+			}
+			continue
+		}
+		lineCells := strings.Split(line[1:], "|")
+		if columns == 0 {
+			columns = len(lineCells)
+		}
+		for _, c := range lineCells {
+			cells = append(cells, strings.TrimSpace(c))
+		}
+	}
+	if attrs != nil && attrs.named["cols"] != "" {
+		columns = 0
+		for _, c := range strings.Split(attrs.named["cols"], ",") {
+			// Columns may be repeated like "3*"
+			if n, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(c, "*", 2)[0])); err == nil && strings.Contains(c, "*") {
+				columns += n
+			} else {
+				columns++
+			}
+		}
+	}
+	if columns == 0 {
+		columns = 1
+	}
+
+	fmt.Fprintf(r.buf, "<table%s>\n", classAttr("", attrs))
+	for row := 0; row*columns < len(cells); row++ {
+		end := (row + 1) * columns
+		if end > len(cells) {
+			end = len(cells)
+		}
+		tag := "td"
+		if row == 0 && header {
+			tag = "th"
+			r.buf.WriteString("<thead>\n")
+		} else if row == 0 || (row == 1 && header) {
+			r.buf.WriteString("<tbody>\n")
+		}
+		r.buf.WriteString("<tr>")
+		for _, cell := range cells[row*columns : end] {
+			fmt.Fprintf(r.buf, "<%s>%s</%s>", tag, r.inline(cell), tag)
+		}
+		r.buf.WriteString("</tr>\n")
+		if row == 0 && header {
+			r.buf.WriteString("</thead>\n")
+		}
+	}
+	if len(cells) > columns || !header {
+		r.buf.WriteString("</tbody>\n")
+	}
+	r.buf.WriteString("</table>\n")
+}
+
+type adocListItem struct {
+	marker string
+	text   []string
+}
+
+// list renders the list starting at line start, items are nested by their
+// marker and may be separated by empty lines. It returns the line after it.
+func (r *adocRenderer) list(lines []string, start int, attrs *adocAttributes) int {
+	var items []*adocListItem
+	i := start
+	for i < len(lines) {
+		line := strings.TrimSpace(lines[i])
+		if m := adocListItemRegexp.FindStringSubmatch(line); m != nil {
+			marker := m[1]
+			if isDigit(marker[0]) {
+				marker = "."
+			}
+			items = append(items, &adocListItem{marker: marker, text: []string{m[2]}})
+			i++
+			continue
+		}
+		if line != "" && !adocDelimiterRegexp.MatchString(line) && !strings.HasPrefix(line, "[") {
+			items[len(items)-1].text = append(items[len(items)-1].text, line)
+			i++
+			continue
+		}
+		// An empty line ends the list unless another item follows
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if line != "" || next >= len(lines) || !adocListItemRegexp.MatchString(strings.TrimSpace(lines[next])) {
+			break
+		}
+		i = next
+	}
+	r.listItems(items, attrs)
+	return i
+}
+
+func (r *adocRenderer) listItems(items []*adocListItem, attrs *adocAttributes) {
+	tag := "ul"
+	if strings.HasPrefix(items[0].marker, ".") {
+		tag = "ol"
+	}
+	fmt.Fprintf(r.buf, "<%s%s>\n", tag, classAttr("", attrs))
+	for i := 0; i < len(items); {
+		item := items[i]
+		r.buf.WriteString("<li>" + r.inline(strings.Join(item.text, "\n")))
+		// Items with other markers are nested
+		end := i + 1
+		for end < len(items) && items[end].marker != items[0].marker {
+			end++
+		}
+		if end > i+1 {
+			r.buf.WriteString("\n")
+			r.listItems(items[i+1:end], nil)
+		}
+		r.buf.WriteString("</li>\n")
+		i = end
+	}
+	fmt.Fprintf(r.buf, "</%s>\n", tag)
+}
+
+// image renders an image with the attributes [alt, width, height]
+func (r *adocRenderer) image(target, attrList string) string {
+	attrs := parseAdocAttributes(attrList)
+	alt := attrs.positionalAt(0)
+	if alt == "" {
+		alt = strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+	}
+	out := fmt.Sprintf(`<img src="%s" alt="%s"`, template.HTMLEscapeString(r.substitute(target)), template.HTMLEscapeString(alt))
+	for i, name := range []string{"width", "height"} {
+		value := attrs.named[name]
+		if value == "" {
+			value = attrs.positionalAt(i + 1)
+		}
+		if value != "" {
+			out += fmt.Sprintf(` %s="%s"`, name, template.HTMLEscapeString(value))
+		}
+	}
+	return out + ">"
+}
+
+var adocAttributeRefRegexp = regexp.MustCompile(`\{([\w-]+)\}`)
+
+// substitute replaces references to defined attributes like {version}
+func (r *adocRenderer) substitute(text string) string {
+	return adocAttributeRefRegexp.ReplaceAllStringFunc(text, func(ref string) string {
+		if value, exists := r.attributes[ref[1:len(ref)-1]]; exists {
+			return value
+		}
+		return ref
+	})
+}
+
+var adocInlineRegexp = regexp.MustCompile(strings.Join([]string{
+	`\+\+\+(.+?)\+\+\+`,
+	"`([^`]+)`",
+	`image:([^\s\[]+)\[([^\]]*)\]`,
+	`link:([^\s\[]+)\[([^\]]*)\]`,
+	`(https?://[^\s\[<]*[^\s\[<.,;:!?)])(?:\[([^\]]*)\])?`,
+	`\*\*(.+?)\*\*`,
+	`__(.+?)__`,
+	`\*(\S(?:.*?\S)?)\*`,
+	`_(\S(?:.*?\S)?)_`,
+	`#(\S(?:.*?\S)?)#`,
+	` \+\n`,
+}, "|"))
+
+// inline renders the inline markup of text
+func (r *adocRenderer) inline(text string) string {
+	text = r.substitute(text)
+	buf := &strings.Builder{}
+	for len(text) > 0 {
+		m := adocInlineRegexp.FindStringSubmatchIndex(text)
+		if m == nil {
+			break
+		}
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+		// Constrained markup has to be surrounded by word boundaries
+		if m[22] >= 0 || m[24] >= 0 || m[26] >= 0 {
+			if (m[0] > 0 && isWordChar(text[m[0]-1])) || (m[1] < len(text) && isWordChar(text[m[1]])) {
+				buf.WriteString(template.HTMLEscapeString(text[:m[0]+1]))
+				text = text[m[0]+1:]
+				continue
+			}
+		}
+		buf.WriteString(template.HTMLEscapeString(text[:m[0]]))
+		switch {
+		case m[2] >= 0:
+			buf.WriteString(group(1))
+		case m[4] >= 0:
+			buf.WriteString("<code>" + template.HTMLEscapeString(group(2)) + "</code>")
+		case m[6] >= 0:
+			buf.WriteString(r.image(group(3), group(4)))
+		case m[10] >= 0:
+			buf.WriteString(r.link(group(5), group(6)))
+		case m[14] >= 0:
+			buf.WriteString(r.link(group(7), group(8)))
+		case m[18] >= 0, m[22] >= 0:
+			buf.WriteString("<strong>" + r.inline(group(9)+group(11)) + "</strong>")
+		case m[20] >= 0, m[24] >= 0:
+			buf.WriteString("<em>" + r.inline(group(10)+group(12)) + "</em>")
+		case m[26] >= 0:
+			buf.WriteString("<mark>" + r.inline(group(13)) + "</mark>")
+		default:
+			buf.WriteString("<br>\n")
+		}
+		text = text[m[1]:]
+	}
+	buf.WriteString(template.HTMLEscapeString(text))
+	return buf.String()
+}
+
+func isWordChar(c byte) bool {
+	return isASCIILetter(c) || isDigit(c) || c == '_'
+}
+
+func (r *adocRenderer) link(url, label string) string {
+	text := template.HTMLEscapeString(url)
+	if label != "" {
+		text = r.inline(label)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, template.HTMLEscapeString(url), text)
+}
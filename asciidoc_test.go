@@ -0,0 +1,155 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAsciiDoc = `= Release Notes
+Jane Doe <jane@example.com>
+v1.2, 2026-10-01
+:product: Show and Tell
+
+// Everything before the first section is the title slide
+
+== What's new in {product}
+
+* *Faster* rendering
+** in _all_ browsers
+* Tables
+
+[.notes]
+--
+Mention the *benchmarks*.
+--
+
+=== Details
+
+[%header,cols="2*"]
+|===
+|Format |Since
+|adoc |1.2
+|md |1.0
+|===
+
+NOTE: Older browsers are still supported.
+
+[WARNING]
+====
+Back up your slides.
+====
+
+== Code
+
+.Greeting
+[source,go]
+----
+include::code/hello.go[tag=main]
+----
+<1> Prints a greeting
+
+image::images/chart.png[Chart,640]
+
+. First
+. Second
+`
+
+const testAsciiDocCode = `package main
+
+// tag::main[]
+func main() {
+	fmt.Println("hello") // <1>
+}
+// end::main[]
+`
+
+func TestAsciiDocSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-adoc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "code"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "code", "hello.go"), []byte(testAsciiDocCode), 0644))
+	docPath := filepath.Join(dir, "notes.adoc")
+	require.NoError(t, ioutil.WriteFile(docPath, []byte(testAsciiDoc), 0644))
+
+	slides, err := DefaultSlidePipeline().RunAll(&Presentation{}, docPath)
+	require.NoError(t, err)
+	require.Len(t, slides, 3)
+
+	assert.Equal(t, "notes-1", slides[0].SectionID)
+	assert.Equal(t, "<h1>Release Notes</h1>\n<p class=\"author\">Jane Doe &lt;jane@example.com&gt;</p>\n<p class=\"revision\">v1.2, 2026-10-01</p>\n",
+		string(slides[0].Content))
+
+	require.Len(t, slides[1].SubSlides, 2)
+	news := slides[1].SubSlides[0]
+	assert.Equal(t, "<h2>What&#39;s new in Show and Tell</h2>\n<ul>\n<li><strong>Faster</strong> rendering\n<ul>\n<li>in <em>all</em> browsers</li>\n</ul>\n</li>\n<li>Tables</li>\n</ul>\n",
+		string(news.Content), "Attributes of the header apply to all slides")
+	assert.Equal(t, "<div>\n<p>Mention the <strong>benchmarks</strong>.</p>\n</div>\n", string(news.Notes))
+
+	details := string(slides[1].SubSlides[1].Content)
+	assert.Contains(t, details, "<table>\n<thead>\n<tr><th>Format</th><th>Since</th></tr>\n</thead>\n<tbody>\n<tr><td>adoc</td><td>1.2</td></tr>\n<tr><td>md</td><td>1.0</td></tr>\n</tbody>\n</table>\n")
+	assert.Contains(t, details, "<div class=\"admonition note\">\n<p class=\"admonition-title\">Note</p>\n<p>Older browsers are still supported.</p>\n</div>\n")
+	assert.Contains(t, details, "<div class=\"admonition warning\">\n<p class=\"admonition-title\">Warning</p>\n<p>Back up your slides.</p>\n</div>\n")
+
+	code := string(slides[2].Content)
+	assert.Contains(t, code, "<div class=\"title\">Greeting</div>\n<pre class=\"chroma\"><code class=\"language-go\">")
+	assert.NotContains(t, code, "package main", "Only the tagged lines are included")
+	assert.NotContains(t, code, "tag::")
+	assert.Contains(t, code, `</span><span class="p">)</span> <b class="conum">(1)</b>`)
+	assert.Contains(t, code, "<ol class=\"callouts\">\n<li value=\"1\">Prints a greeting</li>\n</ol>\n")
+	assert.Contains(t, code, "<p class=\"image\"><img src=\"images/chart.png\" alt=\"Chart\" width=\"640\"></p>\n")
+	assert.Contains(t, code, "<ol>\n<li>First</li>\n<li>Second</li>\n</ol>\n")
+}
+
+func TestAsciiDocIncludedSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-adoc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "parts"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "parts", "more.adoc"),
+		[]byte("== Included\n\nText\n\n[.notes]\n--\nIncluded notes\n--\n\n=== Below\n\nMore\n"), 0644))
+	docPath := filepath.Join(dir, "talk.adoc")
+	require.NoError(t, ioutil.WriteFile(docPath, []byte("== First\n\nHello\n\ninclude::parts/more.adoc[]\n"), 0644))
+
+	slides, err := DefaultSlidePipeline().RunAll(&Presentation{}, docPath)
+	require.NoError(t, err)
+	require.Len(t, slides, 2, "Sections of included files are slides")
+	assert.Equal(t, "<h2>First</h2>\n<p>Hello</p>\n", string(slides[0].Content))
+	require.Len(t, slides[1].SubSlides, 2)
+	included := slides[1].SubSlides[0]
+	assert.Equal(t, "<h2>Included</h2>\n<p>Text</p>\n", string(included.Content))
+	assert.Equal(t, "<div>\n<p>Included notes</p>\n</div>\n", string(included.Notes))
+	assert.Equal(t, "<h3>Below</h3>\n<p>More</p>\n", string(slides[1].SubSlides[1].Content))
+}
+
+func TestAsciiDocInline(t *testing.T) {
+	r := newAdocRenderer(&SlideContext{})
+	for in, expected := range map[string]string{
+		"a *bold* and _italic_ word":       "a <strong>bold</strong> and <em>italic</em> word",
+		"snake_case_name and 2*3*4":        "snake_case_name and 2*3*4",
+		"**un**constrained":                "<strong>un</strong>constrained",
+		"`<code>` and #marked#":            "<code>&lt;code&gt;</code> and <mark>marked</mark>",
+		"see https://example.com/a[docs].": `see <a href="https://example.com/a">docs</a>.`,
+		"see https://example.com.":         `see <a href="https://example.com">https://example.com</a>.`,
+		"pass +++<br>+++ through":          "pass <br> through",
+		"one +\ntwo":                       "one<br>\ntwo",
+	} {
+		assert.Equal(t, expected, r.inline(in), in)
+	}
+}
+
+func TestAsciiDocIncludeLines(t *testing.T) {
+	lines := []string{"a", "b", "c", "d"}
+	selected, err := adocSelectLines(lines, "1..2;4..-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d"}, selected)
+	_, err = adocSelectLines(lines, "3..7")
+	assert.EqualError(t, err, `Line range "3..7" is out of bounds, the file has 4 lines`)
+	_, err = adocTaggedLines(lines, "main")
+	assert.EqualError(t, err, "Tag main not found")
+}
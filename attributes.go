@@ -0,0 +1,206 @@
+package showandtell
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SlideBackground is the background of a slide, see
+// https://github.com/hakimel/reveal.js#slide-backgrounds. Only one of Image,
+// Video and Iframe can be used, Color can be combined with all of them.
+type SlideBackground struct {
+	Color    string `yaml:"color" toml:"color" json:"color"`
+	Image    string `yaml:"image" toml:"image" json:"image"`
+	Video    string `yaml:"video" toml:"video" json:"video"`
+	Iframe   string `yaml:"iframe" toml:"iframe" json:"iframe"`
+	Size     string `yaml:"size" toml:"size" json:"size"`
+	Position string `yaml:"position" toml:"position" json:"position"`
+	Repeat   string `yaml:"repeat" toml:"repeat" json:"repeat"`
+	// Opacity of the image, video or iframe between 0 and 1
+	Opacity *float64 `yaml:"opacity" toml:"opacity" json:"opacity"`
+	// Transition overrides the backgroundTransition of the presentation
+	Transition string `yaml:"transition" toml:"transition" json:"transition"`
+}
+
+// SlideData is the data key of the front matter. It is either a map of
+// additional data-* attributes without the data- prefix, or the path of a
+// CSV, JSON or YAML file relative to the slide, which is rendered as table or
+// chart below the content, see DataSlideParser.
+type SlideData struct {
+	Attributes map[string]string
+	File       string
+}
+
+func (d *SlideData) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&d.File); err == nil {
+		return nil
+	}
+	return unmarshal(&d.Attributes)
+}
+
+func (d *SlideData) UnmarshalTOML(data interface{}) error {
+	switch data := data.(type) {
+	case string:
+		d.File = data
+		return nil
+	case map[string]interface{}:
+		d.Attributes = make(map[string]string, len(data))
+		for name, value := range data {
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("Data attribute %s must be a string", name)
+			}
+			d.Attributes[name] = s
+		}
+		return nil
+	}
+	return fmt.Errorf("Data must be a file name or a table of data attributes")
+}
+
+func (d *SlideData) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &d.File); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &d.Attributes)
+}
+
+var (
+	cssColorRegexp     = regexp.MustCompile(`^(#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})|[a-zA-Z]+|(rgba?|hsla?)\([0-9.,%\s]+\))$`)
+	cssClassRegexp     = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+	dataAttrNameRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+var validVisibilities = map[string]bool{
+	"": true, "hidden": true, "uncounted": true,
+}
+
+// reservedDataAttributes are rendered from other fields of the slide and
+// can't be set through Slide.Data
+var reservedDataAttributes = map[string]bool{
+	"transition": true, "transition-speed": true, "has-notes": true, "state": true,
+	"autoslide": true, "timing": true, "visibility": true, "auto-animate": true,
+}
+
+// validate checks the front matter of the slide. Problems are reported at the
+// line of the key in frontMatter.
+func (s *Slide) validate(frontMatter []byte) error {
+	var errs ConfigErrors
+	report := func(key, format string, args ...interface{}) {
+		errs = append(errs, &ConfigError{
+			File:    s.SourceFile,
+			Line:    keyLine(frontMatter, key),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if s.Transition != nil && !validTransition(*s.Transition) {
+		report("transition", "Invalid transition %q, valid are none, fade, slide, convex, concave and zoom", *s.Transition)
+	}
+	if s.TransitionSpeed != nil && !validTransitionSpeeds[*s.TransitionSpeed] {
+		report("transitionSpeed", "Invalid transition speed %q, valid are default, fast and slow", *s.TransitionSpeed)
+	}
+	if !validVisibilities[s.Visibility] {
+		report("visibility", "Invalid visibility %q, valid are hidden and uncounted", s.Visibility)
+	}
+	for _, class := range s.Classes {
+		if !cssClassRegexp.MatchString(class) {
+			report("classes", "Invalid class name %q", class)
+		}
+	}
+	for _, name := range s.dataNames() {
+		if !dataAttrNameRegexp.MatchString(name) {
+			report("data", "Invalid data attribute name %q, only lower case letters, digits and - are allowed", name)
+		} else if reservedDataAttributes[name] || strings.HasPrefix(name, "background") {
+			report("data", "Data attribute %q is set by its own front matter key", name)
+		}
+	}
+
+	if bg := s.Background; bg != nil {
+		sources := 0
+		for _, src := range []string{bg.Image, bg.Video, bg.Iframe} {
+			if src != "" {
+				sources++
+			}
+		}
+		if sources > 1 {
+			report("background", "Only one of image, video and iframe can be used as background")
+		}
+		if bg.Color != "" && !cssColorRegexp.MatchString(bg.Color) {
+			report("background", "Invalid background color %q", bg.Color)
+		}
+		if bg.Opacity != nil && (*bg.Opacity < 0 || *bg.Opacity > 1) {
+			report("background", "Background opacity must be between 0 and 1, got %v", *bg.Opacity)
+		}
+		if bg.Transition != "" && !validTransition(bg.Transition) {
+			report("background", "Invalid background transition %q", bg.Transition)
+		}
+	}
+	if !validFragments[s.Fragments] {
+		report("fragments", "Invalid fragments %q, valid is lists", s.Fragments)
+	}
+	if c := s.Chart; c != nil && !validChartTypes[c.Type] {
+		report("chart", "Invalid chart type %q, valid are bar, line and pie", c.Type)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Attributes returns the reveal.js attributes of the slide's section besides
+// its id, class and transition.
+func (s *Slide) Attributes() template.HTMLAttr {
+	var attrs []string
+	add := func(name, value string) {
+		if value != "" {
+			attrs = append(attrs, fmt.Sprintf(`%s="%s"`, name, html.EscapeString(value)))
+		}
+	}
+
+	if bg := s.Background; bg != nil {
+		add("data-background-color", bg.Color)
+		add("data-background-image", bg.Image)
+		add("data-background-video", bg.Video)
+		add("data-background-iframe", bg.Iframe)
+		add("data-background-size", bg.Size)
+		add("data-background-position", bg.Position)
+		add("data-background-repeat", bg.Repeat)
+		if bg.Opacity != nil {
+			add("data-background-opacity", strconv.FormatFloat(*bg.Opacity, 'f', -1, 64))
+		}
+		add("data-background-transition", bg.Transition)
+	}
+	add("data-state", s.State)
+	if s.AutoSlide != nil {
+		add("data-autoslide", strconv.FormatUint(*s.AutoSlide, 10))
+	}
+	if s.Timing != nil {
+		add("data-timing", strconv.FormatUint(*s.Timing, 10))
+	}
+	add("data-visibility", s.Visibility)
+	if s.AutoAnimate {
+		attrs = append(attrs, "data-auto-animate")
+	}
+
+	for _, name := range s.dataNames() {
+		add("data-"+name, s.Data.Attributes[name])
+	}
+	return template.HTMLAttr(strings.Join(attrs, " "))
+}
+
+// dataNames returns the names of the data attributes in alphabetical order
+func (s *Slide) dataNames() []string {
+	names := make([]string, 0, len(s.Data.Attributes))
+	for name := range s.Data.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,65 @@
+package showandtell
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlideAttributes(t *testing.T) {
+	s, _, err := DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", []byte(`---
+background:
+  color: "#ff0000"
+  image: images/bg.jpg
+  size: cover
+  position: top left
+  opacity: 0.5
+state: intro
+autoSlide: 5000
+timing: 120
+visibility: uncounted
+autoAnimate: true
+data:
+  menu-title: "Say \"hi\""
+  id-x: "1"
+classes: [dark, full-bleed]
+---
+`))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, DefaultRenderer().ExecuteTemplate(buf, "slide", s))
+	section := buf.String()
+	assert.Contains(t, section, `class="slide dark full-bleed"`)
+	assert.Contains(t, section, `data-background-color="#ff0000" data-background-image="images/bg.jpg" `+
+		`data-background-size="cover" data-background-position="top left" data-background-opacity="0.5" `+
+		`data-state="intro" data-autoslide="5000" data-timing="120" data-visibility="uncounted" data-auto-animate `+
+		`data-id-x="1" data-menu-title="Say &#34;hi&#34;"`)
+}
+
+func TestSlideAttributesValidation(t *testing.T) {
+	_, _, err := DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", []byte(`---
+background:
+  color: "red; display: none"
+  image: a.png
+  video: a.mp4
+  opacity: 2
+visibility: invisible
+data:
+  Upper: x
+  state: x
+classes: ["not a class"]
+---
+`))
+	assert.Equal(t, ConfigErrors{
+		{"slide.md", 7, `Invalid visibility "invisible", valid are hidden and uncounted`},
+		{"slide.md", 11, `Invalid class name "not a class"`},
+		{"slide.md", 8, `Invalid data attribute name "Upper", only lower case letters, digits and - are allowed`},
+		{"slide.md", 8, `Data attribute "state" is set by its own front matter key`},
+		{"slide.md", 2, "Only one of image, video and iframe can be used as background"},
+		{"slide.md", 2, `Invalid background color "red; display: none"`},
+		{"slide.md", 2, "Background opacity must be between 0 and 1, got 2"},
+	}, err)
+}
@@ -0,0 +1,101 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ChapterConfigFile configures a chapter folder. It contains the same keys
+// as the front matter of a slide. The settings apply to the section wrapping
+// the chapter and are the defaults for the front matter of all its slides,
+// including those of nested chapters. Notes and classes only apply to the
+// section wrapping the chapter, data files aren't inherited.
+var ChapterConfigFile = "_chapter.yaml"
+
+// inherit returns a copy of s to use as defaults for the front matter of
+// another slide. Maps and pointers are copied, so decoding front matter into
+// the copy leaves s untouched.
+func (s *Slide) inherit() *Slide {
+	if s == nil {
+		return &Slide{}
+	}
+	c := *s
+	c.Content = ""
+	c.SourceFile = ""
+	c.SubSlides = nil
+	c.SectionID = ""
+	c.Notes = ""
+	c.Classes = nil
+	c.Data.File = ""
+
+	if s.Transition != nil {
+		transition := *s.Transition
+		c.Transition = &transition
+	}
+	if s.TransitionSpeed != nil {
+		speed := *s.TransitionSpeed
+		c.TransitionSpeed = &speed
+	}
+	if s.AutoSlide != nil {
+		autoSlide := *s.AutoSlide
+		c.AutoSlide = &autoSlide
+	}
+	if s.Timing != nil {
+		timing := *s.Timing
+		c.Timing = &timing
+	}
+	if s.Background != nil {
+		bg := *s.Background
+		if bg.Opacity != nil {
+			opacity := *bg.Opacity
+			bg.Opacity = &opacity
+		}
+		c.Background = &bg
+	}
+	if s.Params != nil {
+		c.Params = make(map[string]interface{}, len(s.Params))
+		for k, v := range s.Params {
+			c.Params[k] = v
+		}
+	}
+	if s.Data.Attributes != nil {
+		c.Data.Attributes = make(map[string]string, len(s.Data.Attributes))
+		for k, v := range s.Data.Attributes {
+			c.Data.Attributes[k] = v
+		}
+	}
+	if s.Chart != nil {
+		chart := *s.Chart
+		chart.Series = append([]string(nil), s.Chart.Series...)
+		c.Chart = &chart
+	}
+	c.Tags = append([]string(nil), s.Tags...)
+	return &c
+}
+
+// loadChapter returns the settings of the chapter in dir, which are the
+// settings of its config file on top of the defaults inherited from the
+// parent chapter. The returned slide is never nil.
+func loadChapter(dir string, parent *Slide) (*Slide, error) {
+	chapter := parent.inherit()
+	configPath := filepath.Join(dir, ChapterConfigFile)
+	buf, err := ioutil.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return chapter, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := decodeYAML(configPath, 0, buf, chapter); err != nil {
+		return nil, err
+	}
+	chapter.SourceFile = configPath
+	if err := chapter.validate(buf); err != nil {
+		return nil, err
+	}
+	if chapter.HasNotes() {
+		chapter.Notes = renderNotes([]byte(chapter.Notes))
+	}
+	return chapter, nil
+}
@@ -0,0 +1,100 @@
+package showandtell
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChapterConfig(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-chapter")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(slideDir, "01_chapter", "01_nested"), 0755))
+
+	files := map[string]string{
+		"01_chapter/_chapter.yaml": `
+title: Chapter
+transition: zoom
+background:
+  color: "#000000"
+classes: [dark]
+data: signups.csv
+notes: Chapter notes
+params:
+  speaker: Alice
+`,
+		"01_chapter/01_inherit.md":                "# Inherit",
+		"01_chapter/02_override.md":               "---\ntransition: fade\nclasses: [light]\n---\n# Override",
+		"01_chapter/01_nested/_chapter.yaml":      "background:\n  image: images/bg.png\n",
+		"01_chapter/01_nested/01_nested_slide.md": "# Nested",
+		"02_plain.md":                             "# Plain",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, filepath.FromSlash(name)), []byte(content), 0644))
+	}
+
+	slides, err := ParseSlides(&Presentation{}, slideDir)
+	require.NoError(t, err)
+	require.Len(t, slides, 2)
+
+	chapter := slides[0]
+	require.Len(t, chapter.SubSlides, 3)
+	assert.Equal(t, "Chapter", chapter.Title)
+	assert.Equal(t, "zoom", *chapter.Transition)
+
+	nested := chapter.SubSlides[1]
+	require.Len(t, nested.SubSlides, 1)
+	nestedSlide := nested.SubSlides[0]
+	assert.Equal(t, "zoom", *nestedSlide.Transition)
+	assert.Equal(t, &SlideBackground{Color: "#000000", Image: "images/bg.png"}, nestedSlide.Background)
+
+	inherit := chapter.SubSlides[0]
+	assert.Equal(t, "zoom", *inherit.Transition)
+	assert.Empty(t, inherit.Classes, "Classes only apply to the chapter section")
+	assert.Empty(t, inherit.Data.File, "Data files aren't inherited")
+	assert.Equal(t, "<h1>Inherit</h1>\n", string(inherit.Content))
+	assert.Equal(t, "Alice", inherit.Params["speaker"])
+	assert.False(t, inherit.HasNotes())
+
+	override := chapter.SubSlides[2]
+	assert.Equal(t, "fade", *override.Transition)
+	assert.Equal(t, []string{"light"}, override.Classes)
+	assert.Equal(t, &SlideBackground{Color: "#000000"}, override.Background)
+	assert.Equal(t, "#000000", chapter.Background.Color, "Slides must not change the chapter")
+
+	plain := slides[1]
+	assert.Nil(t, plain.Transition)
+	assert.Nil(t, plain.Background)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, DefaultRenderer().ExecuteTemplate(buf, "subSlides", chapter))
+	out := buf.String()
+	assert.Contains(t, out, `class="chapter dark"`)
+	assert.Equal(t, 1, strings.Count(out, "dark"), "Classes must not be repeated on the slides")
+	assert.Contains(t, out, `class="slide light"`)
+	assert.Contains(t, out, `data-transition="zoom"`)
+	assert.Contains(t, out, `data-background-color="#000000"`)
+	assert.Regexp(t, `(?s)<aside class="notes">\s*<p>Chapter notes</p>\s*</aside>\s*</section>\s*$`, out)
+}
+
+func TestChapterConfigErrors(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-chapter")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+	chapterDir := filepath.Join(slideDir, "01_chapter")
+	require.NoError(t, os.MkdirAll(chapterDir, 0755))
+	configFile := filepath.Join(chapterDir, ChapterConfigFile)
+	require.NoError(t, ioutil.WriteFile(configFile, []byte("transition: fade\nvisibility: invisible\n"), 0644))
+
+	_, err = ParseSlides(&Presentation{}, slideDir)
+	assert.Equal(t, ConfigErrors{
+		{configFile, 2, `Invalid visibility "invisible", valid are hidden and uncounted`},
+	}, err)
+}
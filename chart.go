@@ -0,0 +1,350 @@
+package showandtell
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SlideChart renders the data of a slide as chart, e.g.
+//
+//	data: ../data/signups.csv
+//	chart:
+//	  type: line
+//	  x: week
+//	  series: [signups, cancellations]
+//
+// Bar and line charts show the values of every series for the labels of the
+// x column, pie charts show the values of the first series.
+type SlideChart struct {
+	// Type is bar, line or pie
+	Type string `yaml:"type" toml:"type" json:"type"`
+	// Title is shown above the chart
+	Title string `yaml:"title" toml:"title" json:"title"`
+	// X is the column of the labels, the first column by default
+	X string `yaml:"x" toml:"x" json:"x"`
+	// Series are the columns of the values, by default all numeric columns
+	// except X
+	Series []string `yaml:"series" toml:"series" json:"series"`
+	// XLabel and YLabel are the titles of the axes
+	XLabel string `yaml:"xLabel" toml:"xLabel" json:"xLabel"`
+	YLabel string `yaml:"yLabel" toml:"yLabel" json:"yLabel"`
+	// Width and Height of the chart in pixels, 800x450 by default
+	Width  uint `yaml:"width" toml:"width" json:"width"`
+	Height uint `yaml:"height" toml:"height" json:"height"`
+}
+
+var validChartTypes = map[string]bool{
+	"bar": true, "line": true, "pie": true,
+}
+
+// chartColors are the colors of the series, or of the slices of pie charts
+var chartColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// chartSeries is a column of numbers
+type chartSeries struct {
+	name   string
+	values []float64
+}
+
+// chartData are the labels of the x axis and the series of a chart
+type chartData struct {
+	labels []string
+	series []*chartSeries
+}
+
+// chartData selects the columns of the chart from table
+func (c *SlideChart) chartData(table *dataTable) (*chartData, error) {
+	x := c.X
+	if x == "" && len(table.columns) > 0 {
+		x = table.columns[0]
+	}
+	xIdx := table.column(x)
+	if xIdx < 0 {
+		return nil, fmt.Errorf("Column %s of the chart isn't in the data, columns are %s", x, strings.Join(table.columns, ", "))
+	}
+	data := &chartData{}
+	for _, row := range table.rows {
+		data.labels = append(data.labels, row[xIdx])
+	}
+
+	names := c.Series
+	if len(names) == 0 {
+		for i, column := range table.columns {
+			if i != xIdx && table.isNumeric(i) {
+				names = append(names, column)
+			}
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("The data has no numeric columns to show")
+		}
+	}
+	for _, name := range names {
+		idx := table.column(name)
+		if idx < 0 {
+			return nil, fmt.Errorf("Column %s of the chart isn't in the data, columns are %s", name, strings.Join(table.columns, ", "))
+		}
+		series := &chartSeries{name: name}
+		for i, row := range table.rows {
+			value, err := parseNumber(row[idx])
+			if err != nil {
+				return nil, fmt.Errorf("Value %q in column %s of row %d isn't a number", row[idx], name, i+1)
+			}
+			series.values = append(series.values, value)
+		}
+		data.series = append(data.series, series)
+	}
+	return data, nil
+}
+
+// renderChart renders table as SVG chart
+func (c *SlideChart) renderChart(table *dataTable) (template.HTML, error) {
+	data, err := c.chartData(table)
+	if err != nil {
+		return "", err
+	}
+	width, height := int(c.Width), int(c.Height)
+	if width == 0 {
+		width = 800
+	}
+	if height == 0 {
+		height = 450
+	}
+
+	b := &svgBuilder{}
+	b.start(width, height)
+	top := 10
+	if c.Title != "" {
+		fmt.Fprintf(b, `<title>%s</title>`, svgEscape(c.Title))
+		b.text(width/2, 20, c.Title, "middle", "")
+		top += diagramLineHeight + 10
+	}
+	if c.Type == "pie" {
+		err = c.pie(b, data, top, width, height)
+	} else {
+		err = c.axes(b, data, top, width, height)
+	}
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(fmt.Sprintf("<figure class=\"chart chart-%s\">%s</figure>\n", c.Type, b.end())), nil
+}
+
+// legend writes the names of the series below top at the right of the chart
+// and returns its width
+func (c *SlideChart) legend(b *svgBuilder, names []string, top, right int) int {
+	width := 0
+	for _, name := range names {
+		if w := textWidth(name) + 20; w > width {
+			width = w
+		}
+	}
+	x := right - width
+	for i, name := range names {
+		y := top + i*diagramLineHeight
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, x, y+3, chartColors[i%len(chartColors)])
+		b.text(x+18, y+diagramLineHeight/2, name, "start", "")
+	}
+	return width + 10
+}
+
+// axes draws a bar or line chart
+func (c *SlideChart) axes(b *svgBuilder, data *chartData, top, width, height int) error {
+	min, max := 0.0, 0.0
+	for _, s := range data.series {
+		for _, v := range s.values {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	ticks, decimals, err := chartTicks(min, max)
+	if err != nil {
+		return err
+	}
+	min, max = ticks[0], ticks[len(ticks)-1]
+
+	left := 10
+	if c.YLabel != "" {
+		left += diagramLineHeight + 10
+	}
+	tickWidth := 0
+	for _, t := range ticks {
+		if w := textWidth(formatTick(t, decimals)); w > tickWidth {
+			tickWidth = w
+		}
+	}
+	left += tickWidth + 10
+	right := width - 10
+	if len(data.series) > 1 {
+		names := make([]string, 0, len(data.series))
+		for _, s := range data.series {
+			names = append(names, s.name)
+		}
+		right -= c.legend(b, names, top, right)
+	}
+	bottom := height - 10 - diagramLineHeight
+	if c.XLabel != "" {
+		bottom -= diagramLineHeight + 10
+	}
+	if right <= left || bottom <= top {
+		return fmt.Errorf("The chart is too small, it is %dx%d", width, height)
+	}
+	y := func(v float64) int {
+		return bottom - int(math.Round((v-min)/(max-min)*float64(bottom-top)))
+	}
+
+	// Grid and labels of the y axis
+	for _, t := range ticks {
+		fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#ddd"/>`, left, y(t), right, y(t))
+		b.text(left-8, y(t), formatTick(t, decimals), "end", "#555")
+	}
+	if c.YLabel != "" {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" dominant-baseline="central" transform="rotate(-90 %d %d)">%s</text>`,
+			10+diagramLineHeight/2, (top+bottom)/2, 10+diagramLineHeight/2, (top+bottom)/2, svgEscape(c.YLabel))
+	}
+
+	// Labels of the x axis, every label is centered in its band
+	band := float64(right-left) / math.Max(1, float64(len(data.labels)))
+	center := func(i int) int {
+		return left + int(math.Round(band*(float64(i)+0.5)))
+	}
+	for i, label := range data.labels {
+		b.text(center(i), bottom+diagramLineHeight/2+6, label, "middle", "#555")
+	}
+	if c.XLabel != "" {
+		b.text((left+right)/2, height-10-diagramLineHeight/2, c.XLabel, "middle", "")
+	}
+
+	if c.Type == "bar" {
+		barWidth := band * 0.8 / float64(len(data.series))
+		for j, s := range data.series {
+			for i, v := range s.values {
+				x := left + int(math.Round(band*(float64(i)+0.1)+barWidth*float64(j)))
+				y0, y1 := y(0), y(v)
+				if y1 > y0 {
+					y0, y1 = y1, y0
+				}
+				fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s</title></rect>`,
+					x, y1, int(math.Max(1, math.Round(barWidth))), y0-y1, chartColors[j%len(chartColors)],
+					svgEscape(fmt.Sprintf("%s %s: %s", data.labels[i], s.name, formatValue(v))))
+			}
+		}
+	} else {
+		for j, s := range data.series {
+			color := chartColors[j%len(chartColors)]
+			points := make([]string, len(s.values))
+			for i, v := range s.values {
+				points[i] = fmt.Sprintf("%d,%d", center(i), y(v))
+			}
+			fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="3"/>`, strings.Join(points, " "), color)
+			for i, v := range s.values {
+				fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="4" fill="%s"><title>%s</title></circle>`, center(i), y(v), color,
+					svgEscape(fmt.Sprintf("%s %s: %s", data.labels[i], s.name, formatValue(v))))
+			}
+		}
+	}
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#555"/>`, left, y(0), right, y(0))
+	return nil
+}
+
+// pie draws the first series as pie chart
+func (c *SlideChart) pie(b *svgBuilder, data *chartData, top, width, height int) error {
+	values := data.series[0].values
+	total := 0.0
+	for _, v := range values {
+		if v < 0 {
+			return fmt.Errorf("Pie charts can't show negative values like %s", formatValue(v))
+		}
+		total += v
+	}
+	right := width - 10 - c.legend(b, data.labels, top, width-10)
+	radius := math.Min(float64(right-10), float64(height-top-10)) / 2
+	if radius <= 0 {
+		return fmt.Errorf("The chart is too small, it is %dx%d", width, height)
+	}
+	cx, cy := 10+radius, float64(top)+radius
+	if total == 0 {
+		return nil
+	}
+
+	angle := -math.Pi / 2
+	for i, v := range values {
+		color := chartColors[i%len(chartColors)]
+		title := svgEscape(fmt.Sprintf("%s: %s (%.0f%%)", data.labels[i], formatValue(v), v/total*100))
+		if v == total {
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"><title>%s</title></circle>`, cx, cy, radius, color, title)
+			break
+		}
+		end := angle + v/total*2*math.Pi
+		largeArc := 0
+		if end-angle > math.Pi {
+			largeArc = 1
+		}
+		fmt.Fprintf(b, `<path d="M %.1f %.1f L %.1f %.1f A %.1f %.1f 0 %d 1 %.1f %.1f Z" fill="%s" stroke="#fff"><title>%s</title></path>`,
+			cx, cy, cx+radius*math.Cos(angle), cy+radius*math.Sin(angle), radius, radius, largeArc,
+			cx+radius*math.Cos(end), cy+radius*math.Sin(end), color, title)
+		angle = end
+	}
+	return nil
+}
+
+// chartTicks returns evenly spaced values of the y axis including min and
+// max, and the decimals needed to show them
+func chartTicks(min, max float64) ([]float64, int, error) {
+	if max == min {
+		max = min + 1
+	}
+	if math.IsNaN(max-min) || math.IsInf(max-min, 0) {
+		return nil, 0, fmt.Errorf("The values from %g to %g are too far apart to show", min, max)
+	}
+	// Steps are 1, 2 or 5 times a power of ten
+	raw := (max - min) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, f := range []float64{2, 5, 10} {
+		if step >= raw {
+			break
+		}
+		step = f * magnitude
+	}
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	// Axes have about 5 ticks, more only if the values are too close
+	// together for floats to count the steps
+	var ticks []float64
+	for i := math.Floor(min / step); i*step < max+step/2 && len(ticks) <= chartMaxTicks; i++ {
+		ticks = append(ticks, i*step)
+	}
+	if len(ticks) == 0 || len(ticks) > chartMaxTicks {
+		return nil, 0, fmt.Errorf("The values from %g to %g can't be shown on an axis", min, max)
+	}
+	if ticks[len(ticks)-1] < max {
+		ticks = append(ticks, ticks[len(ticks)-1]+step)
+	}
+	return ticks, decimals, nil
+}
+
+const chartMaxTicks = 12
+
+func formatTick(v float64, decimals int) string {
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// parseNumber parses numbers like 1234.5, 1,234.5, 12% or $5
+func parseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimLeft(s, "$€£"), "%")
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+	if err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+		return 0, fmt.Errorf("%s isn't a finite number", s)
+	}
+	return v, err
+}
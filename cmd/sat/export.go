@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/connctd/showandtell"
+	"github.com/urfave/cli"
+)
+
+var exportCommand = cli.Command{
+	Name:   "export",
+	Usage:  "Export the presentation into other formats",
+	Before: loadPresentation,
+	Subcommands: []cli.Command{
+		{
+			Name:      "pptx",
+			Usage:     "Export the presentation as PowerPoint file",
+			ArgsUsage: "[file]",
+			Action: func(ctx *cli.Context) error {
+				return exportFile(ctx.Args().First(), "presentation.pptx", func(f *os.File) error {
+					return showandtell.ExportPPTX(f, presentation, slideFolder)
+				})
+			},
+		},
+		{
+			Name:      "handout",
+			Usage:     "Export a printable HTML handout with the notes of every slide",
+			ArgsUsage: "[file]",
+			Action: func(ctx *cli.Context) error {
+				return exportFile(ctx.Args().First(), "handout.html", func(f *os.File) error {
+					out, err := showandtell.RenderHandout(presentation, slideFolder)
+					if err != nil {
+						return err
+					}
+					_, err = f.Write(out)
+					return err
+				})
+			},
+		},
+	},
+}
+
+// exportFile creates the target file, defaulting to defaultName in the dist
+// dir, and writes the export into it.
+func exportFile(target, defaultName string, export func(f *os.File) error) error {
+	if target == "" {
+		target = filepath.Join(defaultDistDir, defaultName)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return export(f)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/connctd/showandtell"
+	"github.com/urfave/cli"
+)
+
+var starterTemplate string
+
+func starterTemplateUsage() string {
+	lines := []string{"The starter template to use:"}
+	for _, name := range showandtell.StarterTemplates() {
+		lines = append(lines, fmt.Sprintf("%s (%s)", name, showandtell.StarterTemplateDescription(name)))
+	}
+	return strings.Join(lines, "\n\t")
+}
+
+var initCommand = cli.Command{
+	Name:      "init",
+	Usage:     "Create a new presentation",
+	ArgsUsage: "[dir]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "template",
+			Value:       showandtell.DefaultStarterTemplate,
+			Usage:       starterTemplateUsage(),
+			Destination: &starterTemplate,
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		dir := ctx.Args().First()
+		if dir == "" {
+			dir = "."
+		}
+		if err := showandtell.InitPresentation(dir, starterTemplate); err != nil {
+			return err
+		}
+		fmt.Printf("Created a new presentation in %s, run \"sat serve\" there to view it\n", dir)
+		return nil
+	},
+}
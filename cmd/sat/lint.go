@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/connctd/showandtell"
+	"github.com/urfave/cli"
+)
+
+var lintFormat string
+
+var lintCommand = cli.Command{
+	Name:  "lint",
+	Usage: "Check the presentation and slides for problems",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "format",
+			Value:       "text",
+			Usage:       "The output format, text or json",
+			Destination: &lintFormat,
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		var diagnostics []showandtell.Diagnostic
+		pres, err := showandtell.ParsePresentation(presentationPath)
+		if configErrs, ok := err.(showandtell.ConfigErrors); ok {
+			for _, configErr := range configErrs {
+				diagnostics = append(diagnostics, configErr.Diagnostic())
+			}
+		} else if err != nil {
+			diagnostics = append(diagnostics, showandtell.Diagnostic{
+				File:     presentationPath,
+				Severity: showandtell.SeverityError,
+				Message:  err.Error(),
+			})
+		} else {
+			if err := showandtell.AddCustomFiles(customFileDir); err != nil {
+				return err
+			}
+			diagnostics = showandtell.Lint(pres, slideFolder)
+		}
+
+		switch lintFormat {
+		case "json":
+			if diagnostics == nil {
+				diagnostics = []showandtell.Diagnostic{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(diagnostics); err != nil {
+				return err
+			}
+		case "text":
+			for _, d := range diagnostics {
+				fmt.Println(d)
+			}
+		default:
+			return fmt.Errorf("Unknown format %s", lintFormat)
+		}
+
+		errors := 0
+		for _, d := range diagnostics {
+			if d.Severity == showandtell.SeverityError {
+				errors++
+			}
+		}
+		if errors > 0 {
+			return cli.NewExitError(fmt.Sprintf("Found %d errors", errors), 1)
+		}
+		return nil
+	},
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/connctd/showandtell"
 	"github.com/urfave/cli"
@@ -15,6 +16,22 @@ var (
 	presentation *showandtell.Presentation
 )
 
+// profileFlags select the slides to include in the presentation
+var profileFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "profile",
+		Usage: "Only include the slides selected by the named profile of the presentation config",
+	},
+	cli.StringSliceFlag{
+		Name:  "tags",
+		Usage: "Only include untagged slides and slides with one of these tags, in addition to the profile",
+	},
+	cli.BoolFlag{
+		Name:  "drafts",
+		Usage: "Include draft slides",
+	},
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "sat"
@@ -22,7 +39,7 @@ func main() {
 	app.Version = showandtell.Version
 	app.Description = "Render and serve reveal.js based presentations"
 	app.EnableBashCompletion = true
-	app.Commands = []cli.Command{renderCommand, serveCommand}
+	app.Commands = []cli.Command{renderCommand, serveCommand, exportCommand, initCommand, lintCommand}
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:        "slides",
@@ -45,18 +62,38 @@ func main() {
 			Destination: &customFileDir,
 		},
 	}
-	app.Before = func(ctx *cli.Context) (err error) {
-		presentation, err = showandtell.ParsePresentation(presentationPath)
-		if err != nil {
-			return err
-		}
-		if err := showandtell.AddCustomFiles(customFileDir); err != nil {
-			return err
-		}
-		return nil
-	}
-
 	if err := app.Run(os.Args); err != nil {
 		panic(err)
 	}
 }
+
+// loadPresentation is the Before hook of all commands working on an existing
+// presentation
+func loadPresentation(ctx *cli.Context) (err error) {
+	presentation, err = showandtell.ParsePresentation(presentationPath)
+	if err != nil {
+		return err
+	}
+	if err := showandtell.AddCustomFiles(customFileDir); err != nil {
+		return err
+	}
+	return selectProfile(ctx)
+}
+
+// selectProfile applies the profile flags of the command to the presentation
+func selectProfile(ctx *cli.Context) error {
+	sel := &showandtell.ProfileSelection{
+		Profile: ctx.String("profile"),
+		Drafts:  ctx.Bool("drafts"),
+	}
+	for _, tags := range ctx.StringSlice("tags") {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				sel.Tags = append(sel.Tags, tag)
+			}
+		}
+	}
+	presentation.Selection = sel
+	_, err := presentation.ActiveProfile()
+	return err
+}
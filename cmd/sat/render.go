@@ -10,16 +10,32 @@ import (
 
 var defaultDistDir = "./dist"
 
+var singleFile bool
+
 var renderCommand = cli.Command{
 	Name:    "render",
 	Aliases: []string{"build", "r", "b"},
 	Usage:   "Render the presentation into the dist dir",
+	Before:  loadPresentation,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:        "single-file",
+			Usage:       "Render a self-contained index.html with all assets inlined",
+			Destination: &singleFile,
+		},
+	}, profileFlags...),
 	Action: func(ctx *cli.Context) error {
 		distDir := ctx.Args().First()
 		if distDir == "" {
 			distDir = defaultDistDir
 		}
-		if err := showandtell.EmitRevealJS(distDir); err != nil {
+		render := showandtell.RenderIndex
+		if singleFile {
+			if err := os.MkdirAll(distDir, 0777); err != nil {
+				return err
+			}
+			render = showandtell.RenderSingleFile
+		} else if err := showandtell.EmitRevealJS(distDir); err != nil {
 			return err
 		}
 		indexPath := filepath.Join(distDir, "index.html")
@@ -29,7 +45,7 @@ var renderCommand = cli.Command{
 		}
 		defer indexFile.Close()
 
-		indexBytes, err := showandtell.RenderIndex(presentation, slideFolder)
+		indexBytes, err := render(presentation, slideFolder)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,86 @@
+package showandtell
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigError is a problem in the presentation config or the front matter of
+// a slide, e.g. an unknown key or a value of the wrong type.
+type ConfigError struct {
+	File string
+	// Line in File the problem was found at, 0 if unknown
+	Line    int
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// Diagnostic returns the error as lint result
+func (e *ConfigError) Diagnostic() Diagnostic {
+	return Diagnostic{
+		File:     e.File,
+		Line:     e.Line,
+		Severity: SeverityError,
+		Message:  e.Message,
+	}
+}
+
+// ConfigErrors are all problems found while decoding a config
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var (
+	yamlErrorLineRegexp   = regexp.MustCompile(`^line (\d+): (.*)$`)
+	yamlUnknownKeyRegexp  = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+	yamlDuplicateKeyRegex = regexp.MustCompile(`^field (\S+) already set in type \S+$`)
+)
+
+// decodeYAML strictly decodes in into out. Unknown and duplicate keys are
+// errors. Problems are returned as ConfigErrors, with lines counted from
+// lineOffset+1, which is the line in file the YAML starts at.
+func decodeYAML(file string, lineOffset int, in []byte, out interface{}) error {
+	err := yaml.UnmarshalStrict(in, out)
+	if err == nil {
+		return nil
+	}
+
+	var messages []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	errs := make(ConfigErrors, 0, len(messages))
+	for _, msg := range messages {
+		configErr := &ConfigError{File: file, Message: msg}
+		if m := yamlErrorLineRegexp.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			configErr.Line = line + lineOffset
+			configErr.Message = m[2]
+		}
+		if m := yamlUnknownKeyRegexp.FindStringSubmatch(configErr.Message); m != nil {
+			configErr.Message = fmt.Sprintf("Unknown key %s", m[1])
+		} else if m := yamlDuplicateKeyRegex.FindStringSubmatch(configErr.Message); m != nil {
+			configErr.Message = fmt.Sprintf("Duplicate key %s", m[1])
+		}
+		errs = append(errs, configErr)
+	}
+	return errs
+}
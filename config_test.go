@@ -0,0 +1,55 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePresentationStrict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "presentation.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`name: Strict
+reveal_conifg:
+  controls: true
+theme: white
+outline:
+  - path: chapter
+    slidez: [a.md]
+`), 0644))
+
+	_, err = ParsePresentation(configFile)
+	require.IsType(t, ConfigErrors{}, err)
+	assert.Equal(t, ConfigErrors{
+		{configFile, 2, "Unknown key reveal_conifg"},
+		{configFile, 4, "cannot unmarshal !!str `white` into []string"},
+		{configFile, 7, "Unknown key slidez"},
+	}, err)
+	assert.Contains(t, err.Error(), configFile+":2: Unknown key reveal_conifg")
+}
+
+func TestFrontMatterStrict(t *testing.T) {
+	slide := []byte(`+++
+notes: fine
+transistion: fade
+layout: [not, a, string]
++++
+# Slide
+`)
+	_, _, err := DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", slide)
+	assert.Equal(t, ConfigErrors{
+		{"slide.md", 3, "Unknown key transistion"},
+		{"slide.md", 4, "cannot unmarshal !!seq into string"},
+	}, err)
+
+	_, _, err = DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", []byte("+++\nnotes: [\n+++\n"))
+	require.IsType(t, ConfigErrors{}, err)
+	assert.Equal(t, 2, err.(ConfigErrors)[0].Line)
+}
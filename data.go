@@ -0,0 +1,257 @@
+package showandtell
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	for ext := range dataFormats {
+		RegisterSlideFormat(ext, &DataSlideParser{})
+	}
+	RegisterSlideProcessor(dataProcessor{})
+}
+
+// dataFormats maps the extensions of data files to their format
+var dataFormats = map[string]string{
+	"csv":  "csv",
+	"json": "json",
+	"yaml": "yaml",
+	"yml":  "yaml",
+}
+
+// DataSlideParser renders CSV, JSON and YAML files as table, or as chart if
+// the front matter sets chart. The first line of CSV is the header, JSON and
+// YAML data is a list of objects or a list of lists with the header first:
+//
+//	---
+//	chart: {type: bar, x: quarter}
+//	---
+//	- quarter: Q1
+//	  revenue: 120
+//	- quarter: Q2
+//	  revenue: 150
+//
+// Front matter of JSON files is an object before the list. Slides of other
+// formats show a data file below their content by setting data to its path.
+// Such files have to be outside of the slide folder, as every file in there
+// is a slide, or the presentation has to use an outline.
+type DataSlideParser struct{}
+
+// SplitSlides returns the file as single slide, data isn't executed as
+// template, as JSON lists of lists start with [[
+func (d *DataSlideParser) SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error) {
+	return [][]*SlideSource{{{Body: input, Line: 1, NoTemplate: true}}}, nil
+}
+
+func (d *DataSlideParser) ParseSlide(ctx *SlideContext, input []byte) (template.HTML, error) {
+	table, err := parseDataTable(ctx.SourceFile, input)
+	if err != nil {
+		return "", err
+	}
+	return renderData(ctx, table)
+}
+
+// dataProcessor adds the data file set by the front matter of a slide
+type dataProcessor struct{}
+
+func (dataProcessor) ProcessSlide(ctx *SlideContext, content template.HTML) (template.HTML, error) {
+	if ctx.Data.File == "" {
+		return content, nil
+	}
+	path := ctx.resolvePath(ctx.Data.File)
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	table, err := parseDataTable(path, buf)
+	if err != nil {
+		return "", err
+	}
+	data, err := renderData(ctx, table)
+	if err != nil {
+		return "", err
+	}
+	return content + data, nil
+}
+
+// dataFile returns the path of the data file of the slide, or an empty
+// string if it has none
+func (s *Slide) dataFile() string {
+	if s.Data.File == "" {
+		return ""
+	}
+	if filepath.IsAbs(s.Data.File) {
+		return s.Data.File
+	}
+	return filepath.Join(filepath.Dir(s.SourceFile), filepath.FromSlash(s.Data.File))
+}
+
+// dataTable is a table of data read from a file
+type dataTable struct {
+	columns []string
+	rows    [][]string
+}
+
+// column returns the index of the column name, or -1
+func (t *dataTable) column(name string) int {
+	for i, column := range t.columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isNumeric returns true if all values of the column i are numbers
+func (t *dataTable) isNumeric(i int) bool {
+	for _, row := range t.rows {
+		if _, err := parseNumber(row[i]); err != nil {
+			return false
+		}
+	}
+	return len(t.rows) > 0
+}
+
+// parseDataTable parses the data of the file at path by its extension
+func parseDataTable(path string, input []byte) (*dataTable, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	switch dataFormats[ext] {
+	case "csv":
+		r := csv.NewReader(strings.NewReader(string(input)))
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV data in %s: %s", path, err)
+		}
+		if len(records) == 0 {
+			return &dataTable{}, nil
+		}
+		return &dataTable{columns: records[0], rows: records[1:]}, nil
+	case "json":
+		// Compact JSON has no tabs, which YAML doesn't allow
+		compact := &bytes.Buffer{}
+		if err := json.Compact(compact, input); err != nil {
+			return nil, fmt.Errorf("Invalid JSON data in %s: %s", path, err)
+		}
+		input = compact.Bytes()
+	case "yaml":
+	default:
+		return nil, fmt.Errorf("Data files of type %s are not supported, use csv, json or yaml", ext)
+	}
+
+	// JSON is valid YAML, which keeps the order of keys
+	var objects []yaml.MapSlice
+	if err := yaml.Unmarshal(input, &objects); err == nil {
+		table := &dataTable{}
+		for _, object := range objects {
+			for _, item := range object {
+				if name := dataValue(item.Key); table.column(name) < 0 {
+					table.columns = append(table.columns, name)
+				}
+			}
+		}
+		for _, object := range objects {
+			row := make([]string, len(table.columns))
+			for _, item := range object {
+				row[table.column(dataValue(item.Key))] = dataValue(item.Value)
+			}
+			table.rows = append(table.rows, row)
+		}
+		return table, nil
+	}
+	var lists [][]interface{}
+	if err := yaml.Unmarshal(input, &lists); err != nil {
+		return nil, fmt.Errorf("Invalid data in %s, it must be a list of objects or a list of lists", path)
+	}
+	table := &dataTable{}
+	for i, list := range lists {
+		row := make([]string, len(list))
+		for j, value := range list {
+			row[j] = dataValue(value)
+		}
+		if i == 0 {
+			table.columns = row
+			continue
+		}
+		// Rows are as long as the header
+		for len(row) < len(table.columns) {
+			row = append(row, "")
+		}
+		table.rows = append(table.rows, row[:len(table.columns)])
+	}
+	return table, nil
+}
+
+func dataValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// renderData renders table as chart if the slide sets one, as table otherwise
+func renderData(ctx *SlideContext, table *dataTable) (template.HTML, error) {
+	if ctx.Chart != nil {
+		out, err := ctx.Chart.renderChart(table)
+		if err != nil {
+			return "", fmt.Errorf("Failed to render chart of slide %s: %s", ctx.SourceFile, err)
+		}
+		return out, nil
+	}
+	buf := &strings.Builder{}
+	buf.WriteString("<table class=\"data\">\n<thead>\n<tr>")
+	for _, column := range table.columns {
+		fmt.Fprintf(buf, "<th>%s</th>", template.HTMLEscapeString(column))
+	}
+	buf.WriteString("</tr>\n</thead>\n<tbody>\n")
+	numeric := make([]bool, len(table.columns))
+	for i := range table.columns {
+		numeric[i] = table.isNumeric(i)
+	}
+	for _, row := range table.rows {
+		buf.WriteString("<tr>")
+		for i, value := range row {
+			if numeric[i] {
+				fmt.Fprintf(buf, "<td class=\"number\">%s</td>", template.HTMLEscapeString(value))
+			} else {
+				fmt.Fprintf(buf, "<td>%s</td>", template.HTMLEscapeString(value))
+			}
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>\n")
+	return template.HTML(buf.String()), nil
+}
+
+// dataCSS styles tables of data and scales charts down to the width of the
+// slide
+var dataCSS = `
+table.data {
+	border-collapse: collapse;
+	font-size: 0.8em;
+}
+table.data th, table.data td {
+	padding: 0.2em 0.6em;
+	border-bottom: 1px solid #ccc;
+}
+table.data td.number {
+	text-align: right;
+	font-variant-numeric: tabular-nums;
+}
+figure.chart {
+	margin: 0.5em auto;
+}
+figure.chart svg {
+	max-width: 100%;
+	height: auto;
+}
+`
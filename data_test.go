@@ -0,0 +1,106 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	run := func(path string) string {
+		slides, err := DefaultSlidePipeline().RunAll(&Presentation{}, path)
+		require.NoError(t, err)
+		require.Len(t, slides, 1)
+		return string(slides[0].Content)
+	}
+
+	assert.Equal(t, "<table class=\"data\">\n<thead>\n<tr><th>team</th><th>signups</th></tr>\n</thead>\n<tbody>\n"+
+		"<tr><td>A &amp; B</td><td class=\"number\">1,200</td></tr>\n<tr><td>C</td><td class=\"number\">80</td></tr>\n</tbody>\n</table>\n",
+		run(write("teams.csv", "team,signups\nA & B,\"1,200\"\nC,80\n")))
+
+	assert.Contains(t, run(write("lists.json", `[["week", "signups"], [1, 5], [2]]`)),
+		"<tr><td class=\"number\">2</td><td></td></tr>", "Missing values of JSON lists are empty")
+
+	bar := run(write("weekly.yaml", "---\nchart: {type: bar, title: Weekly}\n---\n- week: W1\n  signups: 5\n  churn: 2\n- week: W2\n  signups: 10\n  churn: -1\n"))
+	assert.True(t, strings.HasPrefix(bar, `<figure class="chart chart-bar"><svg xmlns="http://www.w3.org/2000/svg" width="800" height="450"`), bar)
+	assert.Contains(t, bar, "<title>Weekly</title>")
+	assert.Contains(t, bar, "<title>W2 churn: -1</title></rect>")
+	assert.Equal(t, 4, strings.Count(bar, "<rect x=")-2, "Every value is a bar, the legend has two entries")
+
+	pie := run(write("share.json", "{\"chart\": {\"type\": \"pie\", \"x\": \"browser\"}}\n[{\"browser\": \"Firefox\", \"share\": 25}, {\"browser\": \"Chrome\", \"share\": 75}]"))
+	assert.Contains(t, pie, "<title>Firefox: 25 (25%)</title></path>")
+	assert.Contains(t, pie, "<title>Chrome: 75 (75%)</title></path>")
+
+	// Slides of other formats show the data file set by their front matter
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0755))
+	write("data/signups.csv", "week,signups,churn\n1,5,1\n2,8,2\n")
+	line := run(write("growth.md", "---\ndata: data/signups.csv\nchart:\n  type: line\n  series: [signups]\n  yLabel: Users\n---\n## Growth\n"))
+	assert.True(t, strings.HasPrefix(line, "<h2>Growth</h2>\n<figure class=\"chart chart-line\">"), line)
+	assert.Equal(t, 1, strings.Count(line, "<polyline"))
+	assert.Contains(t, line, "<title>2 signups: 8</title></circle>")
+	assert.Contains(t, line, `transform="rotate(-90 19 216)">Users`)
+	assert.Contains(t, run(write("table.md", "+++\ndata = \"data/signups.csv\"\n+++\n")), "<th>churn</th>")
+}
+
+func TestDataSlideErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string]struct {
+		content  string
+		expected string
+	}{
+		"column.csv": {"---\nchart: {type: bar, series: [users]}\n---\nweek,signups\n1,5\n",
+			"Failed to render chart of slide %s: Column users of the chart isn't in the data, columns are week, signups"},
+		"number.csv": {"---\nchart: {type: line, series: [signups]}\n---\nweek,signups\n1,many\n",
+			"Failed to render chart of slide %s: Value \"many\" in column signups of row 1 isn't a number"},
+		"negative.yaml": {"---\nchart: {type: pie}\n---\n- [name, value]\n- [a, -1]\n",
+			"Failed to render chart of slide %s: Pie charts can't show negative values like -1"},
+		"inf.csv": {"---\nchart: {type: line}\n---\nw,v\na,1\nb,inf\n",
+			"Failed to render chart of slide %s: The data has no numeric columns to show"},
+		"nan.csv": {"---\nchart: {type: bar, series: [v]}\n---\nw,v\na,NaN\nb,1\n",
+			"Failed to render chart of slide %s: Value \"NaN\" in column v of row 1 isn't a number"},
+		"huge.csv": {"---\nchart: {type: line}\n---\nw,v\na,-1e308\nb,1e308\n",
+			"Failed to render chart of slide %s: The values from -1e+308 to 1e+308 are too far apart to show"},
+		"object.yaml": {"week: 1\n", "Invalid data in %s, it must be a list of objects or a list of lists"},
+		"type.csv":    {"---\nchart: {type: radar}\n---\na,b\n", "%s:2: Invalid chart type \"radar\", valid are bar, line and pie"},
+		"missing.md":  {"---\ndata: missing.csv\n---\n", "open %s: no such file or directory"},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(data.content), 0644))
+		_, err := DefaultSlidePipeline().RunAll(&Presentation{}, path)
+		if name == "missing.md" {
+			path = filepath.Join(dir, "missing.csv")
+		}
+		assert.EqualError(t, err, strings.Replace(data.expected, "%s", path, -1), name)
+	}
+}
+
+func TestChartTicks(t *testing.T) {
+	ticks, decimals, err := chartTicks(-1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{-5, 0, 5, 10}, ticks)
+	assert.Equal(t, 0, decimals)
+	ticks, decimals, err = chartTicks(0, 0.3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, decimals)
+	assert.Equal(t, []string{"0.0", "0.1", "0.2", "0.3"}, []string{formatTick(ticks[0], decimals),
+		formatTick(ticks[1], decimals), formatTick(ticks[2], decimals), formatTick(ticks[3], decimals)})
+
+	_, _, err = chartTicks(1e16, 1e16+2)
+	assert.EqualError(t, err, "The values from 1e+16 to 1.0000000000000002e+16 can't be shown on an axis")
+}
@@ -0,0 +1,217 @@
+package showandtell
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterDiagramRenderer("dot", &DotDiagramRenderer{})
+	RegisterDiagramRenderer("graphviz", &DotDiagramRenderer{})
+	RegisterDiagramRenderer("sequence", &SequenceDiagramRenderer{})
+	RegisterDiagramRenderer("plantuml-lite", &SequenceDiagramRenderer{})
+}
+
+// DiagramRenderer renders the source of a diagram to SVG. Fenced code blocks
+// in Markdown slides with the language of a registered renderer are replaced
+// by the diagram, e.g.
+//
+//	```dot
+//	digraph { client -> server }
+//	```
+type DiagramRenderer interface {
+	RenderDiagram(src []byte) ([]byte, error)
+}
+
+var diagramRenderers = map[string]DiagramRenderer{}
+
+// RegisterDiagramRenderer registers the renderer of the diagrams of fenced
+// code blocks with the language lang
+func RegisterDiagramRenderer(lang string, renderer DiagramRenderer) {
+	diagramRenderers[lang] = renderer
+	diagramCache.clear()
+}
+
+// diagramCache keeps rendered diagrams by the hash of their language and
+// source, so only changed diagrams are rendered again on livereload
+var diagramCache = &renderCache{}
+
+// sweepRenderCaches drops the diagrams and math which weren't used since
+// the last call, it is called before every render of a presentation
+func sweepRenderCaches() {
+	diagramCache.sweep()
+	mathCache.sweep()
+}
+
+// renderCache keeps the entries used since the last sweep and those of the
+// previous one, so it doesn't grow with every edit of a served presentation
+type renderCache struct {
+	lock     sync.Mutex
+	entries  map[string]template.HTML
+	previous map[string]template.HTML
+}
+
+func (c *renderCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = nil
+	c.previous = nil
+}
+
+func (c *renderCache) sweep() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.previous = c.entries
+	c.entries = nil
+}
+
+func (c *renderCache) get(key string, render func() (template.HTML, error)) (template.HTML, error) {
+	c.lock.Lock()
+	out, cached := c.entries[key]
+	if !cached {
+		if out, cached = c.previous[key]; cached {
+			c.set(key, out)
+		}
+	}
+	c.lock.Unlock()
+	if cached {
+		return out, nil
+	}
+
+	out, err := render()
+	if err != nil {
+		return "", err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.set(key, out)
+	return out, nil
+}
+
+// set adds an entry, the lock has to be held
+func (c *renderCache) set(key string, out template.HTML) {
+	if c.entries == nil {
+		c.entries = map[string]template.HTML{}
+	}
+	c.entries[key] = out
+}
+
+func contentHash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// renderDiagram renders the fenced code with the language lang as diagram.
+// False is returned if no diagram renderer is registered for lang.
+func renderDiagram(lang, src string) (template.HTML, bool, error) {
+	renderer, exists := diagramRenderers[lang]
+	if !exists {
+		return "", false, nil
+	}
+	out, err := diagramCache.get(contentHash(lang, src), func() (template.HTML, error) {
+		svg, err := renderer.RenderDiagram([]byte(src))
+		if err != nil {
+			return "", fmt.Errorf("Failed to render %s diagram: %s", lang, err)
+		}
+		return template.HTML(fmt.Sprintf("<figure class=\"diagram diagram-%s\">%s</figure>\n", html.EscapeString(lang), svg)), nil
+	})
+	return out, true, err
+}
+
+// diagramCSS scales diagrams down to the width of the slide
+var diagramCSS = `
+figure.diagram {
+	margin: 0.5em auto;
+}
+figure.diagram svg {
+	max-width: 100%;
+	height: auto;
+}
+`
+
+// Measures of the text in diagrams. Text can't be measured without a font,
+// so widths are estimated from the average width of a sans-serif character.
+const (
+	diagramFontSize   = 14
+	diagramCharWidth  = 8
+	diagramLineHeight = 18
+)
+
+func textWidth(s string) int {
+	width := 0
+	for _, line := range strings.Split(s, "\n") {
+		if w := utf8.RuneCountInString(line) * diagramCharWidth; w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// svgBuilder writes an SVG document
+type svgBuilder struct {
+	strings.Builder
+	// id prefixes the IDs of the elements of the document, so they are
+	// unique on a page with more than one document
+	id      string
+	markers []string
+}
+
+func (b *svgBuilder) start(width, height int) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" `+
+		`font-family="sans-serif" font-size="%d">`, width, height, width, height, diagramFontSize)
+}
+
+// marker returns the ID of an arrowhead marker of the given color
+func (b *svgBuilder) marker(open bool, color string) string {
+	kind := "arrow"
+	shape := fmt.Sprintf(`<path d="M 0 0 L 10 5 L 0 10 z" fill="%s"/>`, svgEscape(color))
+	if open {
+		kind = "open-arrow"
+		shape = fmt.Sprintf(`<path d="M 0 0 L 10 5 L 0 10" fill="none" stroke="%s"/>`, svgEscape(color))
+	}
+	id := "sat-" + b.id + "-" + kind + "-" + contentHash(color)[:8]
+	def := fmt.Sprintf(`<marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" `+
+		`orient="auto-start-reverse">%s</marker>`, id, shape)
+	for _, m := range b.markers {
+		if m == def {
+			return id
+		}
+	}
+	b.markers = append(b.markers, def)
+	return id
+}
+
+func (b *svgBuilder) end() []byte {
+	if len(b.markers) > 0 {
+		b.WriteString("<defs>" + strings.Join(b.markers, "") + "</defs>")
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+func svgEscape(s string) string {
+	return html.EscapeString(s)
+}
+
+// text writes text centered at x, lines are centered around y
+func (b *svgBuilder) text(x, y int, text, anchor, color string) {
+	lines := strings.Split(text, "\n")
+	y -= (len(lines) - 1) * diagramLineHeight / 2
+	fill := ""
+	if color != "" {
+		fill = fmt.Sprintf(` fill="%s"`, svgEscape(color))
+	}
+	for i, line := range lines {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="%s" dominant-baseline="central"%s>%s</text>`,
+			x, y+i*diagramLineHeight, anchor, fill, html.EscapeString(line))
+	}
+}
@@ -0,0 +1,161 @@
+package showandtell
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingDiagramRenderer struct {
+	calls int
+}
+
+func (c *countingDiagramRenderer) RenderDiagram(src []byte) ([]byte, error) {
+	c.calls++
+	return []byte("<svg>" + strings.TrimSpace(string(src)) + "</svg>"), nil
+}
+
+func TestMarkdownDiagrams(t *testing.T) {
+	counter := &countingDiagramRenderer{}
+	RegisterDiagramRenderer("counted", counter)
+	defer delete(diagramRenderers, "counted")
+
+	parse := func(md string) string {
+		content, err := (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte(md))
+		require.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, "<h1>Diagram</h1>\n<figure class=\"diagram diagram-counted\"><svg>a</svg></figure>\n",
+		parse("# Diagram\n\n```counted\na\n```\n"))
+	parse("```counted\na\n```\n")
+	assert.Equal(t, 1, counter.calls, "Diagrams must be cached")
+	parse("```counted\nb\n```\n")
+	assert.Equal(t, 2, counter.calls)
+	diagramCache.sweep()
+	parse("```counted\nb\n```\n")
+	diagramCache.sweep()
+	parse("```counted\nb\n```\n")
+	assert.Equal(t, 2, counter.calls, "Diagrams used since the last sweep are kept")
+	parse("```counted\na\n```\n")
+	assert.Equal(t, 3, counter.calls, "Diagrams not used since the last sweep are dropped")
+
+	out := parse("```dot\ndigraph { a -> b }\n```\n")
+	assert.True(t, strings.HasPrefix(out, `<figure class="diagram diagram-dot"><svg `), out)
+	out += parse("```dot\ndigraph { c -> d }\n```\n")
+	ids := regexp.MustCompile(`<marker id="([^"]+)"`).FindAllStringSubmatch(out, -1)
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, ids[0][1], ids[1][1], "Markers of diagrams on one page need unique IDs")
+
+	_, err := (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte("```dot\ndigraph {\n  a -> \n}\n```\n"))
+	assert.EqualError(t, err, `Failed to render dot diagram: Line 3: expected an ID, found "}"`)
+}
+
+// assertSVG checks that svg is well-formed and returns its text
+func assertSVG(t *testing.T, svg []byte) []string {
+	var texts []string
+	decoder := xml.NewDecoder(strings.NewReader(string(svg)))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, string(svg))
+		if data, ok := token.(xml.CharData); ok {
+			texts = append(texts, string(data))
+		}
+	}
+	return texts
+}
+
+func TestDotDiagram(t *testing.T) {
+	svg, err := (&DotDiagramRenderer{}).RenderDiagram([]byte(`
+// Services
+digraph services {
+	node [shape=box style="rounded,filled" fillcolor="#eee"]
+	client [label="Web\nClient" shape=ellipse]
+	client -> api [label="HTTPS"];
+	api -> {users orders} -> db
+	db -> api [style=dashed]
+	db -> db
+	/* undirected edges are not allowed
+	   in a digraph, but parsed anyway */
+	subgraph cluster_ops { monitor }
+}`))
+	require.NoError(t, err)
+	texts := assertSVG(t, svg)
+	assert.Equal(t, []string{"HTTPS", "Web", "Client", "api", "users", "orders", "db", "monitor"}, texts)
+	out := string(svg)
+	assert.Equal(t, 7, strings.Count(out, "marker-end="), "6 edges and a loop")
+	assert.Equal(t, 5, strings.Count(out, `<rect `))
+	assert.Contains(t, out, `<ellipse `)
+	assert.Contains(t, out, `fill="#eee"`)
+	assert.Contains(t, out, `stroke-dasharray="6 4"`)
+	assert.Equal(t, 1, strings.Count(out, "<marker "))
+
+	g, err := parseDot(`graph { rankdir=LR; a -- b; a -- c }`)
+	require.NoError(t, err)
+	l := newDotLayout(g)
+	assert.False(t, g.directed)
+	assert.True(t, l.vertices[g.nodeByID["b"]].x > l.vertices[g.nodeByID["a"]].x, "LR must place ranks left to right")
+	assert.Equal(t, l.vertices[g.nodeByID["b"]].x, l.vertices[g.nodeByID["c"]].x)
+	assert.NotContains(t, string(l.svg("a")), "marker")
+
+	for src, expected := range map[string]string{
+		`digraph { a -> b`:          `Line 1: expected "}", found end of input`,
+		`tree { a }`:                `Line 1: expected graph or digraph, found "tree"`,
+		"digraph {\n a [label=x\n}": `Line 3: expected an ID, found "}"`,
+		`digraph { a -> "b }`:       `Line 1: unterminated string`,
+	} {
+		_, err := parseDot(src)
+		assert.EqualError(t, err, expected, src)
+	}
+}
+
+func TestDotCycles(t *testing.T) {
+	g, err := parseDot(`digraph { a -> b -> c -> a; a -> d }`)
+	require.NoError(t, err)
+	l := newDotLayout(g)
+	assert.Len(t, l.ranks, 3)
+	for _, path := range l.paths {
+		assert.Equal(t, path.edge.from, path.vertices[0].node)
+		assert.Equal(t, path.edge.to, path.vertices[len(path.vertices)-1].node)
+	}
+	assertSVG(t, l.svg("a"))
+}
+
+func TestSequenceDiagram(t *testing.T) {
+	svg, err := (&SequenceDiagramRenderer{}).RenderDiagram([]byte(`@startuml
+title Login
+participant Browser
+participant "Auth Service" as Auth
+' PlantUML comment
+Browser -> Auth : POST /login
+note over Auth : checks\npassword
+Auth->Auth: hash
+Auth-->>Browser: session <cookie>
+@enduml`))
+	require.NoError(t, err)
+	texts := assertSVG(t, svg)
+	assert.Equal(t, []string{"Login", "Browser", "Browser", "Auth Service", "Auth Service",
+		"POST /login", "checks", "password", "hash", "session <cookie>"}, texts)
+	out := string(svg)
+	assert.Equal(t, 1, strings.Count(out, `stroke-dasharray="6 4"`))
+	assert.Regexp(t, `marker id="sat-[0-9a-f]{8}-open-arrow-`, out)
+
+	d, err := parseSequence("A->B: one\nB->C: a very long message between B and C")
+	require.NoError(t, err)
+	d.layout()
+	a, b, c := d.participants[0], d.participants[1], d.participants[2]
+	assert.True(t, a.center < b.center && b.center < c.center)
+	assert.True(t, c.center-b.center > float64(textWidth("a very long message between B and C")))
+
+	_, err = parseSequence("A->B: fine\nthis is not valid")
+	assert.EqualError(t, err, `Line 2: expected a message, note, participant or title, found "this is not valid"`)
+	_, err = parseSequence("note left of A,B: x")
+	assert.EqualError(t, err, `Line 1: notes can only be over two participants`)
+}
@@ -0,0 +1,1004 @@
+package showandtell
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DotDiagramRenderer renders graphs in the Graphviz DOT language with a
+// layered layout like the dot program uses. The statements of subgraphs are
+// part of the graph, but clusters aren't drawn. Besides labels, the node
+// attributes shape (box, ellipse, circle, diamond, plaintext), style (filled,
+// rounded, dashed, dotted), color, fillcolor and fontcolor, the edge
+// attributes label, color, style, dir and arrowhead and the graph attributes
+// rankdir (TB or LR) and label are supported.
+type DotDiagramRenderer struct{}
+
+func (d *DotDiagramRenderer) RenderDiagram(src []byte) ([]byte, error) {
+	g, err := parseDot(string(src))
+	if err != nil {
+		return nil, err
+	}
+	return newDotLayout(g).svg(contentHash(string(src))[:8]), nil
+}
+
+type dotGraph struct {
+	directed bool
+	attrs    map[string]string
+	nodes    []*dotNode
+	nodeByID map[string]*dotNode
+	edges    []*dotEdge
+}
+
+type dotNode struct {
+	id    string
+	attrs map[string]string
+}
+
+func (n *dotNode) label() string {
+	if label, exists := n.attrs["label"]; exists {
+		return dotLabel(label)
+	}
+	return n.id
+}
+
+type dotEdge struct {
+	from, to *dotNode
+	attrs    map[string]string
+}
+
+// dotLabel replaces the escape sequences for line breaks in DOT labels
+func dotLabel(label string) string {
+	label = strings.NewReplacer(`\n`, "\n", `\l`, "\n", `\r`, "\n").Replace(label)
+	return strings.TrimSuffix(label, "\n")
+}
+
+// dotToken is a token of the DOT language. Quoted strings and HTML labels
+// are IDs like any other.
+type dotToken struct {
+	text string
+	id   bool
+	line int
+}
+
+func tokenizeDot(src string) ([]dotToken, error) {
+	var tokens []dotToken
+	line := 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' && (i == 0 || runes[i-1] == '\n'), r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("Line %d: unterminated comment", line)
+			}
+			i += 2
+		case r == '"':
+			start := line
+			buf := &strings.Builder{}
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+					i++
+				} else if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\n' {
+					i++
+					line++
+					continue
+				} else if runes[i] == '\n' {
+					line++
+				}
+				buf.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("Line %d: unterminated string", start)
+			}
+			i++
+			tokens = append(tokens, dotToken{text: buf.String(), id: true, line: start})
+		case r == '<':
+			start := line
+			depth := 0
+			j := i
+			for ; j < len(runes); j++ {
+				if runes[j] == '<' {
+					depth++
+				} else if runes[j] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				} else if runes[j] == '\n' {
+					line++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("Line %d: unterminated HTML label", start)
+			}
+			tokens = append(tokens, dotToken{text: htmlLabelText(string(runes[i+1 : j])), id: true, line: start})
+			i = j + 1
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '>' || runes[i+1] == '-'):
+			tokens = append(tokens, dotToken{text: string(runes[i : i+2]), line: line})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:", r):
+			tokens = append(tokens, dotToken{text: string(r), line: line})
+			i++
+		case r == '_' || r == '.' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '.' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, dotToken{text: string(runes[i:j]), id: true, line: line})
+			i = j
+		default:
+			return nil, fmt.Errorf("Line %d: unexpected character %q", line, r)
+		}
+	}
+	return tokens, nil
+}
+
+// htmlLabelText returns the text of an HTML label, line breaks are kept
+func htmlLabelText(label string) string {
+	label = strings.NewReplacer("<br/>", "\n", "<br>", "\n", "<BR/>", "\n", "<BR>", "\n").Replace(label)
+	buf := &strings.Builder{}
+	inTag := false
+	for _, r := range label {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			buf.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// dotParser parses the DOT grammar of https://graphviz.org/doc/info/lang.html
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *dotGraph
+}
+
+// dotScope holds the default attributes of a graph or subgraph
+type dotScope struct {
+	node, edge map[string]string
+}
+
+func (s *dotScope) copy() *dotScope {
+	return &dotScope{node: copyAttrs(s.node), edge: copyAttrs(s.edge)}
+}
+
+func copyAttrs(attrs map[string]string) map[string]string {
+	c := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		c[k] = v
+	}
+	return c
+}
+
+func parseDot(src string) (*dotGraph, error) {
+	tokens, err := tokenizeDot(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotParser{
+		tokens: tokens,
+		graph:  &dotGraph{attrs: map[string]string{}, nodeByID: map[string]*dotNode{}},
+	}
+	if p.keyword("strict") {
+		p.pos++
+	}
+	switch {
+	case p.keyword("digraph"):
+		p.graph.directed = true
+	case p.keyword("graph"):
+	default:
+		return nil, p.errorf("expected graph or digraph")
+	}
+	p.pos++
+	if t, ok := p.peek(); ok && t.id {
+		p.pos++
+	}
+	if _, err := p.block(&dotScope{node: map[string]string{}, edge: map[string]string{}}, true); err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("Line %d: unexpected %q after the graph", t.line, t.text)
+	}
+	return p.graph, nil
+}
+
+func (p *dotParser) peek() (dotToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return dotToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *dotParser) is(text string) bool {
+	t, ok := p.peek()
+	return ok && !t.id && t.text == text
+}
+
+func (p *dotParser) keyword(keyword string) bool {
+	t, ok := p.peek()
+	return ok && t.id && strings.EqualFold(t.text, keyword)
+}
+
+func (p *dotParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	found := "end of input"
+	if t, ok := p.peek(); ok {
+		line = t.line
+		found = strconv.Quote(t.text)
+	}
+	return fmt.Errorf("Line %d: %s, found %s", line, fmt.Sprintf(format, args...), found)
+}
+
+func (p *dotParser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf("expected %q", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *dotParser) id() (string, error) {
+	t, ok := p.peek()
+	if !ok || !t.id {
+		return "", p.errorf("expected an ID")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// block parses the statements in braces and returns the nodes used in them
+func (p *dotParser) block(scope *dotScope, root bool) ([]*dotNode, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var nodes []*dotNode
+	for !p.is("}") {
+		if _, ok := p.peek(); !ok {
+			return nil, p.errorf("expected \"}\"")
+		}
+		stmtNodes, err := p.statement(scope, root)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, stmtNodes...)
+		if p.is(";") {
+			p.pos++
+		}
+	}
+	p.pos++
+	return nodes, nil
+}
+
+func (p *dotParser) statement(scope *dotScope, root bool) ([]*dotNode, error) {
+	switch {
+	case p.keyword("graph") || p.keyword("node") || p.keyword("edge"):
+		kind := strings.ToLower(p.tokens[p.pos].text)
+		p.pos++
+		attrs, err := p.attrList()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range attrs {
+			switch kind {
+			case "graph":
+				if root {
+					p.graph.attrs[k] = v
+				}
+			case "node":
+				scope.node[k] = v
+			case "edge":
+				scope.edge[k] = v
+			}
+		}
+		return nil, nil
+	}
+
+	if t, ok := p.peek(); ok && t.id && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "=" && !p.tokens[p.pos+1].id {
+		p.pos += 2
+		value, err := p.id()
+		if err != nil {
+			return nil, err
+		}
+		if root {
+			p.graph.attrs[t.text] = value
+		}
+		return nil, nil
+	}
+
+	operands := [][]*dotNode{}
+	operand, isNode, err := p.operand(scope)
+	if err != nil {
+		return nil, err
+	}
+	operands = append(operands, operand)
+	for p.is("->") || p.is("--") {
+		p.pos++
+		next, _, err := p.operand(scope)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	attrs := map[string]string{}
+	if p.is("[") {
+		if attrs, err = p.attrList(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(operands) == 1 {
+		if isNode {
+			for k, v := range attrs {
+				operand[0].attrs[k] = v
+			}
+		}
+		return operand, nil
+	}
+	var nodes []*dotNode
+	for i := 0; i+1 < len(operands); i++ {
+		for _, from := range operands[i] {
+			for _, to := range operands[i+1] {
+				edgeAttrs := copyAttrs(scope.edge)
+				for k, v := range attrs {
+					edgeAttrs[k] = v
+				}
+				p.graph.edges = append(p.graph.edges, &dotEdge{from: from, to: to, attrs: edgeAttrs})
+			}
+		}
+	}
+	for _, o := range operands {
+		nodes = append(nodes, o...)
+	}
+	return nodes, nil
+}
+
+// operand parses a node ID or a subgraph. isNode is true for a node ID.
+func (p *dotParser) operand(scope *dotScope) (nodes []*dotNode, isNode bool, err error) {
+	if p.keyword("subgraph") || p.is("{") {
+		if p.keyword("subgraph") {
+			p.pos++
+			if t, ok := p.peek(); ok && t.id {
+				p.pos++
+			}
+		}
+		nodes, err := p.block(scope.copy(), false)
+		return nodes, false, err
+	}
+	id, err := p.id()
+	if err != nil {
+		return nil, false, err
+	}
+	// Ports are ignored, edges always connect the centers of nodes
+	for p.is(":") {
+		p.pos++
+		if _, err := p.id(); err != nil {
+			return nil, false, err
+		}
+	}
+	return []*dotNode{p.node(id, scope)}, true, nil
+}
+
+func (p *dotParser) node(id string, scope *dotScope) *dotNode {
+	if n, exists := p.graph.nodeByID[id]; exists {
+		return n
+	}
+	n := &dotNode{id: id, attrs: copyAttrs(scope.node)}
+	p.graph.nodes = append(p.graph.nodes, n)
+	p.graph.nodeByID[id] = n
+	return n
+}
+
+func (p *dotParser) attrList() (map[string]string, error) {
+	attrs := map[string]string{}
+	for p.is("[") {
+		p.pos++
+		for !p.is("]") {
+			key, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.id()
+			if err != nil {
+				return nil, err
+			}
+			attrs[key] = value
+			if p.is(",") || p.is(";") {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
+
+// Spacing of the layout in pixels
+const (
+	dotMargin    = 10
+	dotNodeSep   = 30
+	dotRankSep   = 50
+	dotLoopWidth = 30
+)
+
+// dotVertex is a node of the layout. Edges spanning multiple ranks pass
+// through virtual vertices, one on each rank in between.
+type dotVertex struct {
+	node          *dotNode
+	width, height float64
+	rank, order   int
+	x, y          float64
+	in, out       []*dotVertex
+}
+
+// dotPath is the route of an edge through the ranks
+type dotPath struct {
+	edge     *dotEdge
+	vertices []*dotVertex
+}
+
+type dotLayout struct {
+	graph         *dotGraph
+	leftToRight   bool
+	vertices      map[*dotNode]*dotVertex
+	ranks         [][]*dotVertex
+	paths         []*dotPath
+	loops         []*dotEdge
+	width, height float64
+}
+
+func newDotLayout(g *dotGraph) *dotLayout {
+	l := &dotLayout{
+		graph:       g,
+		leftToRight: strings.EqualFold(g.attrs["rankdir"], "LR") || strings.EqualFold(g.attrs["rankdir"], "RL"),
+		vertices:    map[*dotNode]*dotVertex{},
+	}
+	for _, n := range g.nodes {
+		w, h := nodeSize(n)
+		if l.leftToRight {
+			w, h = h, w
+		}
+		l.vertices[n] = &dotVertex{node: n, width: w, height: h}
+	}
+	l.assignRanks()
+	l.order()
+	l.position()
+	return l
+}
+
+func nodeSize(n *dotNode) (float64, float64) {
+	label := n.label()
+	w := float64(textWidth(label) + 20)
+	h := float64(len(strings.Split(label, "\n"))*diagramLineHeight + 16)
+	switch nodeShape(n) {
+	case "ellipse":
+		w, h = w*1.25, h*1.2
+	case "circle":
+		d := math.Max(w, h) * 1.1
+		w, h = d, d
+	case "diamond":
+		w, h = w*1.6, h*1.6
+	}
+	return math.Max(w, 40), math.Max(h, 30)
+}
+
+func nodeShape(n *dotNode) string {
+	switch shape := strings.ToLower(n.attrs["shape"]); shape {
+	case "box", "rect", "rectangle", "square", "note", "tab", "folder", "component", "record", "mrecord", "cylinder":
+		return "box"
+	case "circle", "doublecircle", "point":
+		return "circle"
+	case "diamond":
+		return "diamond"
+	case "plaintext", "plain", "none":
+		return "none"
+	default:
+		return "ellipse"
+	}
+}
+
+// assignRanks puts every node on the rank after its predecessors. Cycles are
+// broken by reversing the edges closing them.
+func (l *dotLayout) assignRanks() {
+	succ := map[*dotNode][]*dotEdge{}
+	for _, e := range l.graph.edges {
+		if e.from != e.to {
+			succ[e.from] = append(succ[e.from], e)
+		}
+	}
+	reversed := map[*dotEdge]bool{}
+	state := map[*dotNode]int{} // 1 while visiting, 2 when done
+	var visit func(n *dotNode)
+	visit = func(n *dotNode) {
+		state[n] = 1
+		for _, e := range succ[n] {
+			switch state[e.to] {
+			case 0:
+				visit(e.to)
+			case 1:
+				reversed[e] = true
+			}
+		}
+		state[n] = 2
+	}
+	for _, n := range l.graph.nodes {
+		if state[n] == 0 {
+			visit(n)
+		}
+	}
+
+	type arc struct{ from, to *dotNode }
+	var arcs []arc
+	indegree := map[*dotNode]int{}
+	for _, e := range l.graph.edges {
+		if e.from == e.to {
+			l.loops = append(l.loops, e)
+			continue
+		}
+		a := arc{e.from, e.to}
+		if reversed[e] {
+			a = arc{e.to, e.from}
+		}
+		arcs = append(arcs, a)
+		indegree[a.to]++
+	}
+	rank := map[*dotNode]int{}
+	queue := []*dotNode{}
+	for _, n := range l.graph.nodes {
+		if indegree[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, a := range arcs {
+			if a.from != n {
+				continue
+			}
+			if rank[n]+1 > rank[a.to] {
+				rank[a.to] = rank[n] + 1
+			}
+			indegree[a.to]--
+			if indegree[a.to] == 0 {
+				queue = append(queue, a.to)
+			}
+		}
+	}
+
+	maxRank := 0
+	for _, n := range l.graph.nodes {
+		v := l.vertices[n]
+		v.rank = rank[n]
+		if v.rank > maxRank {
+			maxRank = v.rank
+		}
+	}
+	l.ranks = make([][]*dotVertex, maxRank+1)
+	for _, n := range l.graph.nodes {
+		v := l.vertices[n]
+		l.ranks[v.rank] = append(l.ranks[v.rank], v)
+	}
+
+	for _, e := range l.graph.edges {
+		if e.from == e.to {
+			continue
+		}
+		from, to := l.vertices[e.from], l.vertices[e.to]
+		if reversed[e] {
+			from, to = to, from
+		}
+		path := &dotPath{edge: e, vertices: []*dotVertex{from}}
+		prev := from
+		for r := from.rank + 1; r < to.rank; r++ {
+			virtual := &dotVertex{rank: r, width: 10, height: 10}
+			l.ranks[r] = append(l.ranks[r], virtual)
+			prev.out = append(prev.out, virtual)
+			virtual.in = append(virtual.in, prev)
+			path.vertices = append(path.vertices, virtual)
+			prev = virtual
+		}
+		prev.out = append(prev.out, to)
+		to.in = append(to.in, prev)
+		path.vertices = append(path.vertices, to)
+		if reversed[e] {
+			for i, j := 0, len(path.vertices)-1; i < j; i, j = i+1, j-1 {
+				path.vertices[i], path.vertices[j] = path.vertices[j], path.vertices[i]
+			}
+		}
+		l.paths = append(l.paths, path)
+	}
+}
+
+// order reduces crossing edges by sorting the vertices of every rank by the
+// average position of their neighbors, sweeping down and up a few times
+func (l *dotLayout) order() {
+	setOrder := func() {
+		for _, rank := range l.ranks {
+			for i, v := range rank {
+				v.order = i
+			}
+		}
+	}
+	setOrder()
+	best := l.snapshot()
+	bestCrossings := l.crossings()
+	barycenter := func(v *dotVertex, neighbors []*dotVertex) float64 {
+		if len(neighbors) == 0 {
+			return float64(v.order)
+		}
+		sum := 0.0
+		for _, n := range neighbors {
+			sum += float64(n.order)
+		}
+		return sum / float64(len(neighbors))
+	}
+	for i := 0; i < 8 && bestCrossings > 0; i++ {
+		for r := 1; r < len(l.ranks); r++ {
+			rank := l.ranks[r]
+			sort.SliceStable(rank, func(a, b int) bool {
+				return barycenter(rank[a], rank[a].in) < barycenter(rank[b], rank[b].in)
+			})
+			setOrder()
+		}
+		for r := len(l.ranks) - 2; r >= 0; r-- {
+			rank := l.ranks[r]
+			sort.SliceStable(rank, func(a, b int) bool {
+				return barycenter(rank[a], rank[a].out) < barycenter(rank[b], rank[b].out)
+			})
+			setOrder()
+		}
+		if c := l.crossings(); c < bestCrossings {
+			best, bestCrossings = l.snapshot(), c
+		}
+	}
+	l.ranks = best
+	setOrder()
+}
+
+func (l *dotLayout) snapshot() [][]*dotVertex {
+	s := make([][]*dotVertex, len(l.ranks))
+	for i, rank := range l.ranks {
+		s[i] = append([]*dotVertex(nil), rank...)
+	}
+	return s
+}
+
+// crossings counts the crossing edges between adjacent ranks
+func (l *dotLayout) crossings() int {
+	count := 0
+	for r := 0; r+1 < len(l.ranks); r++ {
+		var arcs [][2]int
+		for _, v := range l.ranks[r] {
+			for _, w := range v.out {
+				arcs = append(arcs, [2]int{v.order, w.order})
+			}
+		}
+		for i := range arcs {
+			for j := i + 1; j < len(arcs); j++ {
+				if (arcs[i][0]-arcs[j][0])*(arcs[i][1]-arcs[j][1]) < 0 {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// position places the ranks below each other and every vertex near the
+// average position of its neighbors
+func (l *dotLayout) position() {
+	y := float64(dotMargin)
+	for _, rank := range l.ranks {
+		height := 0.0
+		for _, v := range rank {
+			height = math.Max(height, v.height)
+		}
+		for _, v := range rank {
+			v.y = y + height/2
+		}
+		y += height + dotRankSep
+	}
+
+	maxWidth := 0.0
+	for _, rank := range l.ranks {
+		maxWidth = math.Max(maxWidth, rankWidth(rank))
+	}
+	for _, rank := range l.ranks {
+		x := dotMargin + (maxWidth-rankWidth(rank))/2
+		for _, v := range rank {
+			v.x = x + v.width/2
+			x += v.width + dotNodeSep
+		}
+	}
+
+	align := func(rank []*dotVertex, neighbors func(v *dotVertex) []*dotVertex) {
+		desired := make([]float64, len(rank))
+		for i, v := range rank {
+			desired[i] = v.x
+			if n := neighbors(v); len(n) > 0 {
+				sum := 0.0
+				for _, w := range n {
+					sum += w.x
+				}
+				desired[i] = sum / float64(len(n))
+			}
+		}
+		// Keep the order and the separation, then shift the rank so it is on
+		// average where it should be
+		shift := 0.0
+		for i, v := range rank {
+			v.x = desired[i]
+			if i > 0 {
+				prev := rank[i-1]
+				v.x = math.Max(v.x, prev.x+(prev.width+v.width)/2+dotNodeSep)
+			}
+			shift += v.x - desired[i]
+		}
+		shift /= float64(len(rank))
+		for _, v := range rank {
+			v.x -= shift
+		}
+	}
+	for i := 0; i < 4; i++ {
+		for r := 1; r < len(l.ranks); r++ {
+			align(l.ranks[r], func(v *dotVertex) []*dotVertex { return v.in })
+		}
+		for r := len(l.ranks) - 2; r >= 0; r-- {
+			align(l.ranks[r], func(v *dotVertex) []*dotVertex { return v.out })
+		}
+	}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, rank := range l.ranks {
+		for _, v := range rank {
+			minX = math.Min(minX, v.x-v.width/2)
+			maxX = math.Max(maxX, v.x+v.width/2)
+		}
+	}
+	if len(l.loops) > 0 {
+		maxX += dotLoopWidth
+	}
+	if math.IsInf(minX, 1) {
+		minX, maxX = 0, 0
+	}
+	for _, rank := range l.ranks {
+		for _, v := range rank {
+			v.x += dotMargin - minX
+		}
+	}
+	l.width = maxX - minX + 2*dotMargin
+	l.height = y - dotRankSep + dotMargin
+
+	if l.leftToRight {
+		for _, v := range l.allVertices() {
+			v.x, v.y = v.y, v.x
+			v.width, v.height = v.height, v.width
+		}
+		l.width, l.height = l.height, l.width
+	}
+}
+
+func rankWidth(rank []*dotVertex) float64 {
+	w := 0.0
+	for i, v := range rank {
+		if i > 0 {
+			w += dotNodeSep
+		}
+		w += v.width
+	}
+	return w
+}
+
+func (l *dotLayout) allVertices() []*dotVertex {
+	var all []*dotVertex
+	for _, rank := range l.ranks {
+		all = append(all, rank...)
+	}
+	return all
+}
+
+// boundary returns the point where the line from the center of v towards
+// (x, y) leaves the shape of v
+func (v *dotVertex) boundary(x, y float64) (float64, float64) {
+	dx, dy := x-v.x, y-v.y
+	if v.node == nil || (dx == 0 && dy == 0) {
+		return v.x, v.y
+	}
+	w, h := v.width/2, v.height/2
+	var t float64
+	switch nodeShape(v.node) {
+	case "ellipse", "circle":
+		t = 1 / math.Sqrt(dx*dx/(w*w)+dy*dy/(h*h))
+	case "diamond":
+		t = 1 / (math.Abs(dx)/w + math.Abs(dy)/h)
+	default:
+		t = math.Min(w/math.Abs(dx), h/math.Abs(dy))
+	}
+	return v.x + dx*t, v.y + dy*t
+}
+
+func (l *dotLayout) svg(id string) []byte {
+	b := &svgBuilder{id: id}
+	label := dotLabel(l.graph.attrs["label"])
+	labelHeight := 0
+	if label != "" {
+		labelHeight = len(strings.Split(label, "\n"))*diagramLineHeight + dotMargin
+		l.width = math.Max(l.width, float64(textWidth(label)+2*dotMargin))
+	}
+	// Labels of edges are placed right of them
+	for _, path := range l.paths {
+		if label := dotLabel(path.edge.attrs["label"]); label != "" {
+			x, _ := edgeLabelPosition(path)
+			l.width = math.Max(l.width, x+float64(textWidth(label))+dotMargin)
+		}
+	}
+	for _, e := range l.loops {
+		v := l.vertices[e.from]
+		right := v.x + v.width/2 + dotLoopWidth + dotMargin
+		if label := dotLabel(e.attrs["label"]); label != "" {
+			right += float64(textWidth(label))
+		}
+		l.width = math.Max(l.width, right)
+	}
+	b.start(int(math.Ceil(l.width)), int(math.Ceil(l.height))+labelHeight)
+
+	for _, path := range l.paths {
+		l.drawEdge(b, path)
+	}
+	for _, e := range l.loops {
+		l.drawLoop(b, e)
+	}
+	for _, n := range l.graph.nodes {
+		drawNode(b, l.vertices[n])
+	}
+	if label != "" {
+		b.text(int(l.width/2), int(l.height)+labelHeight/2, label, "middle", "")
+	}
+	return b.end()
+}
+
+func drawNode(b *svgBuilder, v *dotVertex) {
+	n := v.node
+	style := n.attrs["style"]
+	stroke := attrOr(n.attrs["color"], "currentColor")
+	fill := "none"
+	if strings.Contains(style, "filled") {
+		fill = attrOr(n.attrs["fillcolor"], attrOr(n.attrs["color"], "lightgrey"))
+	}
+	shapeAttrs := fmt.Sprintf(`fill="%s" stroke="%s"%s`, svgEscape(fill), svgEscape(stroke), dashAttr(style))
+
+	switch nodeShape(n) {
+	case "box":
+		radius := 0
+		if strings.Contains(style, "rounded") {
+			radius = 8
+		}
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%d" %s/>`,
+			v.x-v.width/2, v.y-v.height/2, v.width, v.height, radius, shapeAttrs)
+	case "ellipse":
+		fmt.Fprintf(b, `<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" %s/>`, v.x, v.y, v.width/2, v.height/2, shapeAttrs)
+	case "circle":
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" %s/>`, v.x, v.y, v.width/2, shapeAttrs)
+		if strings.EqualFold(n.attrs["shape"], "doublecircle") {
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" %s/>`, v.x, v.y, v.width/2-4, shapeAttrs)
+		}
+	case "diamond":
+		fmt.Fprintf(b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" %s/>`,
+			v.x, v.y-v.height/2, v.x+v.width/2, v.y, v.x, v.y+v.height/2, v.x-v.width/2, v.y, shapeAttrs)
+	}
+	if strings.EqualFold(n.attrs["shape"], "point") {
+		return
+	}
+	b.text(int(v.x), int(v.y), n.label(), "middle", attrOr(n.attrs["fontcolor"], "currentColor"))
+}
+
+func (l *dotLayout) drawEdge(b *svgBuilder, path *dotPath) {
+	e := path.edge
+	points := make([][2]float64, len(path.vertices))
+	for i, v := range path.vertices {
+		points[i] = [2]float64{v.x, v.y}
+	}
+	first, last := path.vertices[0], path.vertices[len(path.vertices)-1]
+	points[0][0], points[0][1] = first.boundary(points[1][0], points[1][1])
+	n := len(points) - 1
+	points[n][0], points[n][1] = last.boundary(points[n-1][0], points[n-1][1])
+
+	d := &strings.Builder{}
+	fmt.Fprintf(d, "M %.1f %.1f", points[0][0], points[0][1])
+	for _, p := range points[1:] {
+		fmt.Fprintf(d, " L %.1f %.1f", p[0], p[1])
+	}
+	l.drawEdgePath(b, e, d.String())
+
+	if label := dotLabel(e.attrs["label"]); label != "" {
+		x, y := edgeLabelPosition(path)
+		b.text(int(x), int(y), label, "start", attrOr(e.attrs["fontcolor"], "currentColor"))
+	}
+}
+
+// edgeLabelPosition returns the start of the label of an edge, right of the
+// middle of the edge
+func edgeLabelPosition(path *dotPath) (float64, float64) {
+	mid := len(path.vertices) / 2
+	from, to := path.vertices[mid-1], path.vertices[mid]
+	return (from.x+to.x)/2 + 6, (from.y + to.y) / 2
+}
+
+func (l *dotLayout) drawLoop(b *svgBuilder, e *dotEdge) {
+	v := l.vertices[e.from]
+	right := v.x + v.width/2
+	d := fmt.Sprintf("M %.1f %.1f C %.1f %.1f %.1f %.1f %.1f %.1f", right, v.y-v.height/4,
+		right+dotLoopWidth, v.y-v.height/2, right+dotLoopWidth, v.y+v.height/2, right, v.y+v.height/4)
+	l.drawEdgePath(b, e, d)
+	if label := dotLabel(e.attrs["label"]); label != "" {
+		b.text(int(right+dotLoopWidth)+4, int(v.y), label, "start", attrOr(e.attrs["fontcolor"], "currentColor"))
+	}
+}
+
+func (l *dotLayout) drawEdgePath(b *svgBuilder, e *dotEdge, d string) {
+	color := attrOr(e.attrs["color"], "currentColor")
+	markers := ""
+	dir := strings.ToLower(e.attrs["dir"])
+	if dir == "" && l.graph.directed {
+		dir = "forward"
+	}
+	if strings.EqualFold(e.attrs["arrowhead"], "none") && dir == "forward" {
+		dir = "none"
+	}
+	if dir == "forward" || dir == "both" {
+		markers += fmt.Sprintf(` marker-end="url(#%s)"`, b.marker(false, color))
+	}
+	if dir == "back" || dir == "both" {
+		markers += fmt.Sprintf(` marker-start="url(#%s)"`, b.marker(false, color))
+	}
+	fmt.Fprintf(b, `<path d="%s" fill="none" stroke="%s"%s%s/>`, d, svgEscape(color), dashAttr(e.attrs["style"]), markers)
+}
+
+func dashAttr(style string) string {
+	switch {
+	case strings.Contains(style, "dashed"):
+		return ` stroke-dasharray="6 4"`
+	case strings.Contains(style, "dotted"):
+		return ` stroke-dasharray="2 3"`
+	}
+	return ""
+}
+
+func attrOr(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
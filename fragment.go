@@ -0,0 +1,179 @@
+package showandtell
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// Fragments of Markdown slides are shown one after another. An attribute
+// block at the end of a paragraph, heading or the first paragraph of a list
+// item adds classes, an ID or other attributes to it:
+//
+//	- shown first {.fragment}
+//	- shown second {.fragment .fade-up}
+//
+//	Shown together with the first {.fragment data-fragment-index=0}
+//
+// With fragments set to lists in the front matter every list item is a
+// fragment. Fragments are numbered with data-fragment-index in the order of
+// the slide, a fragment with an index set continues the numbering.
+
+var validFragments = map[string]bool{
+	"": true, "lists": true,
+}
+
+var (
+	attributeBlockRegexp = regexp.MustCompile(`\s*\{((?:\s*(?:[.#][\w-]+|[\w-]+=(?:"[^"]*"|[^\s"}]+)))+)\s*\}\s*$`)
+	attributeRegexp      = regexp.MustCompile(`([.#])([\w-]+)|([\w-]+)=(?:"([^"]*)"|([^\s"}]+))`)
+)
+
+// htmlAttributes are the attributes added to the opening tag of an element
+type htmlAttributes struct {
+	id      string
+	classes []string
+	// other attributes as name and value pairs
+	other [][2]string
+}
+
+func (a *htmlAttributes) hasClass(class string) bool {
+	for _, c := range a.classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *htmlAttributes) get(name string) (string, bool) {
+	for _, attr := range a.other {
+		if attr[0] == name {
+			return attr[1], true
+		}
+	}
+	return "", false
+}
+
+func (a *htmlAttributes) String() string {
+	out := &strings.Builder{}
+	if a.id != "" {
+		fmt.Fprintf(out, ` id="%s"`, html.EscapeString(a.id))
+	}
+	if len(a.classes) > 0 {
+		fmt.Fprintf(out, ` class="%s"`, html.EscapeString(strings.Join(a.classes, " ")))
+	}
+	for _, attr := range a.other {
+		fmt.Fprintf(out, ` %s="%s"`, attr[0], html.EscapeString(attr[1]))
+	}
+	return out.String()
+}
+
+// parseAttributeBlock parses the attributes of a block like
+// {.fragment #id data-fragment-index=2}
+func parseAttributeBlock(block string) *htmlAttributes {
+	attrs := &htmlAttributes{}
+	for _, m := range attributeRegexp.FindAllStringSubmatch(block, -1) {
+		switch {
+		case m[1] == "#":
+			attrs.id = m[2]
+		case m[1] == ".":
+			if !attrs.hasClass(m[2]) {
+				attrs.classes = append(attrs.classes, m[2])
+			}
+		default:
+			attrs.other = append(attrs.other, [2]string{m[3], m[4] + m[5]})
+		}
+	}
+	return attrs
+}
+
+// markdownAttributes removes the attribute blocks from the text of the
+// Markdown document root and returns the attributes of the elements. If
+// fragments is lists, every list item is a fragment.
+func markdownAttributes(root *blackfriday.Node, fragments string) map[*blackfriday.Node]*htmlAttributes {
+	attributes := map[*blackfriday.Node]*htmlAttributes{}
+	var elements []*blackfriday.Node
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch node.Type {
+		case blackfriday.Item:
+			if fragments == "lists" && node.ListFlags&(blackfriday.ListTypeDefinition|blackfriday.ListTypeTerm) == 0 {
+				attributes[node] = &htmlAttributes{classes: []string{"fragment"}}
+			}
+			elements = append(elements, node)
+		case blackfriday.Paragraph, blackfriday.Heading:
+			last := node.LastChild
+			if last == nil || last.Type != blackfriday.Text {
+				return blackfriday.GoToNext
+			}
+			loc := attributeBlockRegexp.FindSubmatchIndex(last.Literal)
+			if loc == nil {
+				return blackfriday.GoToNext
+			}
+			attrs := parseAttributeBlock(string(last.Literal[loc[2]:loc[3]]))
+			last.Literal = last.Literal[:loc[0]]
+			// The first paragraph of a list item sets the attributes of
+			// the item
+			target := node
+			if node.Type == blackfriday.Paragraph && node.Parent.Type == blackfriday.Item && node.Prev == nil {
+				target = node.Parent
+			}
+			if existing, exists := attributes[target]; exists {
+				classes := existing.classes
+				for _, class := range attrs.classes {
+					if !existing.hasClass(class) {
+						classes = append(classes, class)
+					}
+				}
+				attrs.classes = classes
+			}
+			attributes[target] = attrs
+			if target == node {
+				elements = append(elements, node)
+			}
+		}
+		return blackfriday.GoToNext
+	})
+
+	// Number the fragments in the order of the slide
+	index := 0
+	for _, node := range elements {
+		attrs, exists := attributes[node]
+		if !exists || !attrs.hasClass("fragment") {
+			continue
+		}
+		if value, exists := attrs.get("data-fragment-index"); exists {
+			if i, err := strconv.Atoi(value); err == nil {
+				index = i + 1
+			}
+			continue
+		}
+		attrs.other = append(attrs.other, [2]string{"data-fragment-index", strconv.Itoa(index)})
+		index++
+	}
+	return attributes
+}
+
+// addAttributes adds attrs to the first opening tag in out
+func addAttributes(out []byte, attrs *htmlAttributes) []byte {
+	start := bytes.IndexByte(out, '<')
+	if start < 0 {
+		return out
+	}
+	end := bytes.IndexByte(out[start:], '>')
+	if end < 0 {
+		return out
+	}
+	end += start
+	if out[end-1] == '/' {
+		end--
+	}
+	return append(append(append([]byte{}, out[:end]...), attrs.String()...), out[end:]...)
+}
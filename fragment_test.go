@@ -0,0 +1,60 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderFragments(t *testing.T, fragments, input string) string {
+	t.Helper()
+	ctx := &SlideContext{}
+	ctx.Fragments = fragments
+	out, err := (&MarkdownSlideParser{}).ParseSlide(ctx, []byte(input))
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestMarkdownFragments(t *testing.T) {
+	assert.Equal(t, "<h2 class=\"fragment\" data-fragment-index=\"0\">Title</h2>\n\n"+
+		"<ul>\n<li class=\"fragment fade-up\" data-fragment-index=\"1\">first</li>\n<li>always <em>shown</em></li>\n</ul>\n\n"+
+		"<p id=\"last\" class=\"fragment highlight-red\" data-fragment-index=\"2\">Last\nparagraph</p>\n\n"+
+		"<p>Set of {braces} and {.class}: stays</p>\n",
+		renderFragments(t, "", "## Title {.fragment}\n\n- first {.fragment .fade-up}\n- always *shown*\n\n"+
+			"Last\nparagraph\n{#last .fragment .highlight-red}\n\nSet of {braces} and {.class}: stays\n"))
+
+	assert.Equal(t, "<ol>\n<li class=\"fragment\" data-fragment-index=\"0\">one\n\n"+
+		"<ul>\n<li class=\"fragment\" data-fragment-index=\"1\">nested</li>\n</ul></li>\n"+
+		"<li class=\"fragment\" data-fragment-index=\"3\">three</li>\n"+
+		"<li class=\"fragment\" data-fragment-index=\"4\">four</li>\n"+
+		"<li class=\"fragment grow\" data-fragment-index=\"2\">two</li>\n</ol>\n",
+		renderFragments(t, "lists", "1. one\n   - nested\n1. three {data-fragment-index=3}\n1. four\n1. two {.grow data-fragment-index=2}\n"),
+		"Fragments with an index continue the numbering")
+
+	assert.Equal(t, "<h2 id=\"x\" class=\"fragment\" data-fragment-index=\"0\">Title</h2>\n\n<h3 id=\"plain\">Plain</h3>\n",
+		renderFragments(t, "", "## Title {#x .fragment}\n\n### Plain {#plain}\n"), "Headings take ID and classes of the attribute block")
+
+	assert.Equal(t, "<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>\n", renderFragments(t, "lists", "Term\n: Definition\n"),
+		"Definition lists aren't fragments")
+	assert.Equal(t, "<pre class=\"chroma\"><code><span class=\"line\"><span class=\"cl\">- item {.fragment}\n</span></span></code></pre>\n",
+		renderFragments(t, "", "```\n- item {.fragment}\n```\n"))
+}
+
+func TestMarkdownFragmentsFrontMatter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-fragments")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	slidePath := filepath.Join(dir, "slide.md")
+	require.NoError(t, ioutil.WriteFile(slidePath, []byte("---\nfragments: lists\n---\n- a\n- b\n"), 0644))
+	s, err := DefaultSlidePipeline().Run(&Presentation{}, slidePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(s.Content), `<li class="fragment" data-fragment-index="1">b</li>`)
+
+	require.NoError(t, ioutil.WriteFile(slidePath, []byte("---\nfragments: paragraphs\n---\n"), 0644))
+	_, err = DefaultSlidePipeline().Run(&Presentation{}, slidePath)
+	assert.EqualError(t, err, slidePath+`:2: Invalid fragments "paragraphs", valid is lists`)
+}
@@ -0,0 +1,191 @@
+package showandtell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	frontMatterDelimiter     = []byte(`+++`)
+	yamlFrontMatterDelimiter = []byte(`---`)
+)
+
+// Front matter formats, see frontMatterFormat
+const (
+	frontMatterYAML = "yaml"
+	frontMatterTOML = "toml"
+	frontMatterJSON = "json"
+)
+
+// frontMatterFormat returns the format of the front matter of a slide:
+//
+//	---   YAML, up to the next line starting with ---
+//	+++   TOML, up to the next +++. As all versions before used YAML here,
+//	      front matter which isn't TOML and starts with a YAML key is read
+//	      as YAML, and so is all of it with legacy_front_matter set.
+//	{     a JSON object
+//
+// An empty string is returned if the slide has no front matter.
+func frontMatterFormat(in []byte, pres *Presentation) string {
+	switch {
+	case bytes.HasPrefix(in, frontMatterDelimiter):
+		if fm, _ := parseFrontMatter(in); pres.LegacyFrontMatter || isLegacyFrontMatter(fm) {
+			return frontMatterYAML
+		}
+		return frontMatterTOML
+	case bytes.HasPrefix(in, yamlFrontMatterDelimiter) && yamlFrontMatterEnd(in) >= 0:
+		return frontMatterYAML
+	case bytes.HasPrefix(in, []byte("{")):
+		return frontMatterJSON
+	}
+	return ""
+}
+
+var yamlKeyRegexp = regexp.MustCompile(`^\s*"?[\w-]+"?:(\s|$)`)
+
+// isLegacyFrontMatter returns true if front matter between +++ fails to
+// decode as TOML and its first key is written as in YAML
+func isLegacyFrontMatter(fm []byte) bool {
+	var v map[string]interface{}
+	if _, err := toml.Decode(string(fm), &v); err == nil {
+		return false
+	}
+	for _, line := range strings.Split(string(fm), "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return yamlKeyRegexp.MatchString(line)
+		}
+	}
+	return false
+}
+
+// yamlFrontMatterEnd returns the index of the line closing front matter
+// started with ---, or -1. Unlike +++, a single --- is common at the start of
+// Markdown, so it is only front matter if it is closed.
+func yamlFrontMatterEnd(in []byte) int {
+	idx := bytes.Index(in[len(yamlFrontMatterDelimiter):], append([]byte("\n"), yamlFrontMatterDelimiter...))
+	if idx < 0 {
+		return -1
+	}
+	return idx + len(yamlFrontMatterDelimiter) + 1
+}
+
+// parseFrontMatter splits the front matter off the content of a slide. The
+// front matter returned starts on the first line of in, so line numbers
+// within it match the lines of the file.
+func parseFrontMatter(in []byte) (fm []byte, content []byte) {
+	switch {
+	case bytes.HasPrefix(in, frontMatterDelimiter):
+		parts := bytes.SplitN(in, frontMatterDelimiter, 3)
+		if len(parts) < 3 {
+			return parts[1], []byte{}
+		}
+		return parts[1], parts[2]
+
+	case bytes.HasPrefix(in, yamlFrontMatterDelimiter):
+		end := yamlFrontMatterEnd(in)
+		if end < 0 {
+			break
+		}
+		return in[len(yamlFrontMatterDelimiter):end], in[end+len(yamlFrontMatterDelimiter):]
+
+	case bytes.HasPrefix(in, []byte("{")):
+		decoder := json.NewDecoder(bytes.NewReader(in))
+		var obj json.RawMessage
+		if err := decoder.Decode(&obj); err != nil {
+			// Let decoding the front matter report the problem
+			return in, []byte{}
+		}
+		end := decoder.InputOffset()
+		return in[:end], in[end:]
+	}
+	return []byte{}, in
+}
+
+// decodeFrontMatter decodes front matter of the given format into s
+func decodeFrontMatter(file, format string, fm []byte, s *Slide) error {
+	switch format {
+	case frontMatterYAML:
+		return decodeYAML(file, 0, fm, s)
+	case frontMatterTOML:
+		return decodeTOML(file, fm, s)
+	case frontMatterJSON:
+		return decodeJSON(file, fm, s)
+	}
+	return fmt.Errorf("Unknown front matter format %s", format)
+}
+
+var tomlErrorLineRegexp = regexp.MustCompile(`^Near line (\d+) \(last key parsed '[^']*'\): (.*)$`)
+
+func decodeTOML(file string, in []byte, out interface{}) error {
+	md, err := toml.Decode(string(in), out)
+	if err != nil {
+		configErr := &ConfigError{File: file, Message: err.Error()}
+		if m := tomlErrorLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			configErr.Line, _ = strconv.Atoi(m[1])
+			configErr.Message = m[2]
+		}
+		return ConfigErrors{configErr}
+	}
+	var errs ConfigErrors
+	for _, key := range md.Undecoded() {
+		if len(key) > 1 && key[0] == "data" {
+			// The data table is decoded by SlideData itself
+			continue
+		}
+		errs = append(errs, &ConfigError{
+			File:    file,
+			Line:    keyLine(in, key[0]),
+			Message: fmt.Sprintf("Unknown key %s", key),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var jsonUnknownFieldRegexp = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+func decodeJSON(file string, in []byte, out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(in))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(out)
+	if err == nil {
+		return nil
+	}
+
+	configErr := &ConfigError{File: file, Message: strings.TrimPrefix(err.Error(), "json: ")}
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		configErr.Line = bytes.Count(in[:e.Offset], []byte("\n")) + 1
+	case *json.UnmarshalTypeError:
+		configErr.Line = bytes.Count(in[:e.Offset], []byte("\n")) + 1
+	default:
+		if m := jsonUnknownFieldRegexp.FindStringSubmatch(err.Error()); m != nil {
+			configErr.Line = keyLine(in, m[1])
+			configErr.Message = fmt.Sprintf("Unknown key %s", m[1])
+		}
+	}
+	return ConfigErrors{configErr}
+}
+
+// keyLine returns the line of a top level key in YAML, TOML or JSON, or 0
+func keyLine(src []byte, key string) int {
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), `"`)
+		if !strings.HasPrefix(line, key) {
+			continue
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(line, key), `"`), " \t")
+		if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "=") {
+			return i + 1
+		}
+	}
+	return 0
+}
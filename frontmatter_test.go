@@ -0,0 +1,74 @@
+package showandtell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontMatterFormats(t *testing.T) {
+	for name, data := range map[string]struct {
+		pres *Presentation
+		in   string
+	}{
+		"yaml": {&Presentation{}, "---\ntransition: zoom\nparams:\n  color: red\n---\nbody"},
+		"toml": {&Presentation{}, "+++\ntransition = \"zoom\"\n[params]\ncolor = \"red\"\n+++\nbody"},
+		"json": {&Presentation{}, "{\n\"transition\": \"zoom\",\n\"params\": {\"color\": \"red\"}\n}\nbody"},
+		"legacy yaml": {&Presentation{LegacyFrontMatter: true},
+			"+++\ntransition: zoom\nparams:\n  color: red\n+++\nbody"},
+		"yaml between +++": {&Presentation{},
+			"+++\ntransition: zoom\nparams:\n  color: red\n+++\nbody"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, body, err := DefaultSlidePipeline().FrontMatter(data.pres, "slide.md", []byte(data.in))
+			require.NoError(t, err)
+			require.NotNil(t, s.Transition)
+			assert.Equal(t, "zoom", *s.Transition)
+			assert.Equal(t, "red", s.Params["color"])
+			assert.Equal(t, "\nbody", string(body))
+		})
+	}
+}
+
+func TestFrontMatterWithoutDelimiters(t *testing.T) {
+	// A horizontal rule at the start of a Markdown slide is no front matter
+	in := []byte("---\n# Heading\n")
+	s, body, err := DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", in)
+	require.NoError(t, err)
+	assert.Nil(t, s.Transition)
+	assert.Equal(t, in, body)
+}
+
+func TestFrontMatterErrors(t *testing.T) {
+	for name, data := range map[string]struct {
+		in       string
+		expected ConfigErrors
+	}{
+		"toml unknown key": {"+++\nnotes = \"fine\"\ntransistion = \"zoom\"\n+++\n",
+			ConfigErrors{{"slide.md", 3, "Unknown key transistion"}}},
+		"toml syntax": {"+++\nnotes = \"fine\"\ntransition = \n+++\n",
+			ConfigErrors{{"slide.md", 3, "expected value but found '\\n' instead"}}},
+		"json unknown key": {"{\n\"notes\": \"fine\",\n\"transistion\": \"zoom\"\n}\n",
+			ConfigErrors{{"slide.md", 3, "Unknown key transistion"}}},
+		"json type": {"{\n\"notes\": \"fine\",\n\"layout\": 1\n}\n",
+			ConfigErrors{{"slide.md", 3, "cannot unmarshal number into Go struct field Slide.layout of type string"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := DefaultSlidePipeline().FrontMatter(&Presentation{}, "slide.md", []byte(data.in))
+			assert.Equal(t, data.expected, err)
+		})
+	}
+}
+
+func TestLegacyFrontMatter(t *testing.T) {
+	pres := &Presentation{LegacyFrontMatter: true}
+	_, _, err := DefaultSlidePipeline().FrontMatter(pres, "slide.md", []byte("+++\ntransition = \"zoom\"\n+++\n"))
+	assert.Error(t, err, "TOML isn't read with legacy_front_matter set")
+
+	slides, err := ParseSlides(pres, "test_slides")
+	require.NoError(t, err)
+	chapter := slides[len(slides)-1]
+	require.Len(t, chapter.SubSlides, 2)
+	assert.Contains(t, string(chapter.SubSlides[1].Notes), "Some notes about this slide")
+}
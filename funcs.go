@@ -0,0 +1,154 @@
+package showandtell
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultFuncMap returns the functions available in slide bodies and layouts.
+//
+// Functions working with files resolve relative paths against the directory
+// of the slide using them:
+//
+//	include "path"                  embeds the content of another file as is
+//	code "path" ["lines" ["lang" ["highlight"]]]
+//	                                embeds a source file as highlighted code
+//	                                block, lines is a range like "10-20",
+//	                                "10-" or "7", lang defaults to the file
+//	                                extension and highlight are the lines to
+//	                                highlight one after another, like "1-3|5"
+//	slideLink "sectionID"           returns the link to another slide, e.g.
+//	                                [see here]([[ slideLink "03_chapter" ]])
+//	asset "path"                    returns the path of a custom file, e.g.
+//	                                "images/logo.png", failing if it is missing
+//
+// Dates and strings:
+//
+//	now                      the current time
+//	date "layout" [time]     formats time, or now, with a Go time layout
+//	upper, lower, title, trim
+//	replace "s" "old" "new"
+//	contains, hasPrefix, hasSuffix
+//	split "s" "sep", join list "sep"
+func DefaultFuncMap() template.FuncMap {
+	slideOnly := func(name string) func(...interface{}) (string, error) {
+		return func(...interface{}) (string, error) {
+			return "", fmt.Errorf("%s can only be used in slides", name)
+		}
+	}
+	return template.FuncMap{
+		"include":   slideOnly("include"),
+		"code":      slideOnly("code"),
+		"slideLink": slideLink,
+		"asset":     asset,
+
+		"now":       time.Now,
+		"date":      date,
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+		"title":     strings.Title,
+		"trim":      strings.TrimSpace,
+		"replace":   func(s, old, new string) string { return strings.Replace(s, old, new, -1) },
+		"contains":  strings.Contains,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"split":     strings.Split,
+		"join":      func(list []string, sep string) string { return strings.Join(list, sep) },
+	}
+}
+
+// FuncMap returns the functions which depend on the slide being rendered
+func (ctx *SlideContext) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"include": ctx.include,
+		"code":    ctx.code,
+	}
+}
+
+func (ctx *SlideContext) resolvePath(relPath string) string {
+	if filepath.IsAbs(relPath) {
+		return relPath
+	}
+	return filepath.Join(filepath.Dir(ctx.SourceFile), filepath.FromSlash(relPath))
+}
+
+func (ctx *SlideContext) include(relPath string) (template.HTML, error) {
+	buf, err := ioutil.ReadFile(ctx.resolvePath(relPath))
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf), nil
+}
+
+func (ctx *SlideContext) code(relPath string, args ...string) (template.HTML, error) {
+	if len(args) > 3 {
+		return "", fmt.Errorf("code expects at most a line range, a language and lines to highlight, got %d arguments", len(args))
+	}
+	buf, err := ioutil.ReadFile(ctx.resolvePath(relPath))
+	if err != nil {
+		return "", err
+	}
+	src := string(buf)
+	firstLine := 1
+	if len(args) > 0 && args[0] != "" {
+		if src, firstLine, err = selectLines(src, args[0]); err != nil {
+			return "", err
+		}
+	}
+	lang := strings.TrimPrefix(filepath.Ext(relPath), ".")
+	if len(args) > 1 && args[1] != "" {
+		lang = args[1]
+	}
+	steps := ""
+	if len(args) > 2 {
+		steps = args[2]
+	}
+	return highlightCode(&ctx.Presentation, src, lang, steps, firstLine, steps != "")
+}
+
+// selectLines returns the lines of src within lineRange, which is either a
+// single line "7" or a range "10-20" where the end may be omitted. Lines are
+// counted from 1. The number of the first line selected is returned as well.
+func selectLines(src, lineRange string) (string, int, error) {
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	parts := strings.SplitN(lineRange, "-", 2)
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid line range %q", lineRange)
+	}
+	end := start
+	if len(parts) == 2 {
+		if strings.TrimSpace(parts[1]) == "" {
+			end = len(lines)
+		} else if end, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return "", 0, fmt.Errorf("Invalid line range %q", lineRange)
+		}
+	}
+	if start < 1 || end < start || end > len(lines) {
+		return "", 0, fmt.Errorf("Line range %q is out of bounds, the file has %d lines", lineRange, len(lines))
+	}
+	return strings.Join(lines[start-1:end], "\n") + "\n", start, nil
+}
+
+func slideLink(sectionID string) template.URL {
+	return template.URL("#/" + sectionID)
+}
+
+func asset(assetPath string) (string, error) {
+	if _, err := FindAsset(assetPath); err != nil {
+		return "", err
+	}
+	return assetPath, nil
+}
+
+func date(layout string, t ...time.Time) string {
+	if len(t) == 0 {
+		return time.Now().Format(layout)
+	}
+	return t[0].Format(layout)
+}
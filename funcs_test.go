@@ -0,0 +1,75 @@
+package showandtell
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func executeSlideTemplate(t *testing.T, ctx *SlideContext, body string) (string, error) {
+	tmpl := DefaultRenderer()
+	tmpl.Funcs(ctx.FuncMap())
+	tmpl, err := tmpl.Parse(body)
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, ctx)
+	return buf.String(), err
+}
+
+func TestSlideFuncs(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-funcs")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "main.go"),
+		[]byte("package main\n\nfunc main() {\n\tprintln(\"<hi>\")\n}\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "snippet.html"), []byte("<b>included</b>"), 0644))
+	require.NoError(t, imagesBox.AddString("funcs.png", "png"))
+
+	ctx := &SlideContext{
+		Slide:        Slide{SourceFile: filepath.Join(slideDir, "01_slide.md")},
+		Presentation: Presentation{Name: "funcs"},
+	}
+
+	for _, data := range []struct {
+		body     string
+		expected string
+	}{
+		{`[[ include "snippet.html" ]]`, `<b>included</b>`},
+		{`[[ code "main.go" "4" ]]`, `<pre class="chroma"><code class="language-go"><span class="line"><span class="cl">` +
+			`	<span class="nb">println</span><span class="p">(</span><span class="s">&#34;&lt;hi&gt;&#34;</span><span class="p">)</span>` +
+			"\n</span></span></code></pre>\n"},
+		{`[[ code "main.go" "1" "text" ]]`, `<pre class="chroma"><code class="language-text"><span class="line"><span class="cl">package main` +
+			"\n</span></span></code></pre>\n"},
+		{`[[ code "main.go" "3-4" "" "4" ]]`, `<pre class="chroma"><code class="language-go">` +
+			`<span class="line"><span class="ln">3</span><span class="cl"><span class="kd">func</span> <span class="nf">main</span><span class="p">()</span> <span class="p">{</span>` +
+			"\n</span></span>" + `<span class="line hl"><span class="ln">4</span><span class="cl">` +
+			`	<span class="nb">println</span><span class="p">(</span><span class="s">&#34;&lt;hi&gt;&#34;</span><span class="p">)</span>` +
+			"\n</span></span></code></pre>\n"},
+		{`[link]([[ slideLink "03_chapter" ]])`, `[link](#/03_chapter)`},
+		{`[[ asset "images/funcs.png" ]]`, `images/funcs.png`},
+		{`[[ date "2006" ]]`, time.Now().Format("2006")},
+		{`[[ .Name | upper ]] [[ replace "a-b" "-" "_" ]] [[ join (split "a,b" ",") ";" ]]`, `FUNCS a_b a;b`},
+	} {
+		out, err := executeSlideTemplate(t, ctx, data.body)
+		require.NoError(t, err, data.body)
+		assert.Equal(t, data.expected, out, data.body)
+	}
+
+	for _, body := range []string{
+		`[[ include "missing.html" ]]`,
+		`[[ code "main.go" "4-10" ]]`,
+		`[[ code "main.go" "foo" ]]`,
+		`[[ code "main.go" "3-5" "go" "2|x" ]]`,
+		`[[ asset "images/missing.png" ]]`,
+	} {
+		_, err := executeSlideTemplate(t, ctx, body)
+		assert.Error(t, err, body)
+	}
+}
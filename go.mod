@@ -3,6 +3,8 @@ module github.com/connctd/showandtell
 go 1.12
 
 require (
+	github.com/BurntSushi/toml v0.3.1
+	github.com/alecthomas/chroma v0.10.0
 	github.com/fsnotify/fsnotify v1.4.7
 	github.com/gobuffalo/depgen v0.1.1 // indirect
 	github.com/gobuffalo/genny v0.1.1 // indirect
@@ -14,11 +16,11 @@ require (
 	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
 	github.com/sirupsen/logrus v1.4.1
 	github.com/stretchr/objx v0.2.0 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.7.0
 	github.com/urfave/cli v1.20.0
 	github.com/vardius/message-bus v1.1.3
 	golang.org/x/crypto v0.0.0-20190506204251-e1dfcc566284 // indirect
-	golang.org/x/net v0.0.0-20190503192946-f4e77d36d62c // indirect
+	golang.org/x/net v0.0.0-20190503192946-f4e77d36d62c
 	golang.org/x/sys v0.0.0-20190507053917-2953c62de483 // indirect
 	golang.org/x/text v0.3.2 // indirect
 	golang.org/x/tools v0.0.0-20190506145303-2d16b83fe98c // indirect
@@ -0,0 +1,252 @@
+package showandtell
+
+import (
+	"bytes"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var handoutTmpl = `
+[[ define "handout" ]]
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="utf-8">
+		<title>[[ .Name ]]</title>
+		<style>[[ handoutCSS ]]</style>
+		<style>[[ layoutCSS ]]</style>
+		<style>[[ .HighlightCSS ]]</style>
+		<style>[[ diagramCSS ]]</style>
+		<style>[[ dataCSS ]]</style>
+	</head>
+	<body>
+		<header>
+			<h1>[[ .Name ]]</h1>
+			[[ with .Description ]]<p class="description">[[ . ]]</p>[[ end ]]
+		</header>
+		<nav class="toc">
+			<h2>Contents</h2>
+			[[ template "handoutTOC" .Entries ]]
+		</nav>
+		<main class="reveal">
+			[[ range .Entries ]][[ template "handoutEntry" . ]][[ end ]]
+		</main>
+	</body>
+</html>
+[[ end ]]
+
+[[ define "handoutTOC" ]]
+<ol>
+[[ range . ]]
+	<li>
+		<a href="#[[ .SectionID ]]">[[ .Title ]]</a>
+		[[ if .Children ]][[ template "handoutTOC" .Children ]][[ end ]]
+	</li>
+[[ end ]]
+</ol>
+[[ end ]]
+
+[[ define "handoutEntry" ]]
+[[ if .Children ]]
+<section class="chapter" id="[[ .SectionID ]]">
+	<h2 class="chapter-title">[[ .Title ]]</h2>
+	[[ if .HasNotes ]]
+	<div class="notes">
+	[[ .Notes ]]
+	</div>
+	[[ end ]]
+	[[ range .Children ]][[ template "handoutEntry" . ]][[ end ]]
+</section>
+[[ else ]]
+<article class="handout-slide" id="[[ .SectionID ]]">
+	<div class="slide-number">[[ .Number ]]</div>
+	<div class="slide-content">
+	[[ .Content ]]
+	</div>
+	[[ if .HasNotes ]]
+	<div class="notes">
+	[[ .Notes ]]
+	</div>
+	[[ end ]]
+</article>
+[[ end ]]
+[[ end ]]
+`
+
+var handoutCSS = `
+body {
+	font-family: sans-serif;
+	max-width: 50em;
+	margin: 2em auto;
+	padding: 0 1em;
+	color: #222;
+}
+.toc a {
+	color: inherit;
+}
+.chapter {
+	break-before: page;
+}
+.chapter-title {
+	border-bottom: 2px solid #222;
+}
+.handout-slide {
+	break-inside: avoid;
+	margin: 1.5em 0;
+}
+.slide-number {
+	font-size: 0.8em;
+	color: #666;
+}
+.slide-content {
+	border: 1px solid #222;
+	padding: 1em;
+	overflow: hidden;
+}
+.slide-content img {
+	max-width: 100%;
+}
+.slide-content pre {
+	white-space: pre-wrap;
+}
+.slide-content pre code {
+	display: block;
+	padding: 0.5em;
+}
+.slide-content pre.chroma code.fragment {
+	display: none;
+}
+.notes {
+	margin-top: 0.5em;
+	padding: 0 1em;
+	font-size: 0.9em;
+	border-left: 3px solid #aaa;
+}
+@media print {
+	body {
+		margin: 0;
+		max-width: none;
+	}
+}
+`
+
+// handoutEntry is either a slide or a chapter with the slides in Children
+type handoutEntry struct {
+	*Slide
+	Title    string
+	Number   int
+	Children []*handoutEntry
+}
+
+type handout struct {
+	*Presentation
+	Entries []*handoutEntry
+}
+
+// RenderHandout renders a static, print friendly document of the
+// presentation. Every slide is shown in a box followed by its notes, chapters
+// become sections and a table of contents links to all of them.
+func RenderHandout(pres *Presentation, slideFolder string) ([]byte, error) {
+	slides, err := ParseSlides(pres, slideFolder)
+	if err != nil {
+		return nil, err
+	}
+	pres.Slides = slides
+
+	number := 0
+	data := &handout{
+		Presentation: pres,
+		Entries:      newHandoutEntries(slides, &number),
+	}
+
+	tmpl := DefaultRenderer()
+	tmpl.Funcs(template.FuncMap{
+		"handoutCSS": func() template.CSS { return template.CSS(handoutCSS) },
+	})
+	if _, err := tmpl.Parse(handoutTmpl); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(buf, "handout", data); err != nil {
+		return nil, err
+	}
+
+	// Images are inlined, so the handout can be opened and printed anywhere
+	inliner := &assetInliner{slideFolder: slideFolder}
+	out := inliner.inlineHTML(buf.Bytes())
+	return out, inliner.err
+}
+
+func newHandoutEntries(slides []*Slide, number *int) []*handoutEntry {
+	entries := make([]*handoutEntry, 0, len(slides))
+	for _, s := range slides {
+		e := &handoutEntry{Slide: s}
+		if len(s.SubSlides) > 0 {
+			e.Children = newHandoutEntries(s.SubSlides, number)
+			e.Title = s.Title
+			if e.Title == "" {
+				e.Title = e.Children[0].Title
+			}
+			if e.Title == e.Children[0].SectionID {
+				e.Title = chapterName(s.SourceFile)
+			}
+		} else {
+			*number++
+			e.Number = *number
+			e.Title = s.Title
+			if e.Title == "" {
+				e.Title = slideTitle(s.Content)
+			}
+			if e.Title == "" {
+				e.Title = s.SectionID
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// slideTitle returns the text of the first heading in content
+func slideTitle(content template.HTML) string {
+	nodes, err := parseHTMLFragment(string(content))
+	if err != nil {
+		return ""
+	}
+	var find func(n *html.Node) string
+	find = func(n *html.Node) string {
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			return strings.Join(strings.Fields(textContent(n)), " ")
+		case atom.Aside:
+			return ""
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if title := find(child); title != "" {
+				return title
+			}
+		}
+		return ""
+	}
+	for _, n := range nodes {
+		if title := find(n); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+var orderPrefixRegexp = regexp.MustCompile(`^[0-9]+[_\-. ]*`)
+
+// chapterName turns a chapter folder like "03_the_chapter" into "the chapter"
+func chapterName(dir string) string {
+	name := orderPrefixRegexp.ReplaceAllString(filepath.Base(dir), "")
+	if name == "" {
+		return filepath.Base(dir)
+	}
+	return strings.Replace(name, "_", " ", -1)
+}
@@ -0,0 +1,44 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHandout(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-handout")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_intro.md"), []byte(`+++
+notes: Greet the *audience*
++++
+# Welcome
+`), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(slideDir, "02_the_details"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "02_the_details", ChapterConfigFile), []byte("notes: Explain the *details*\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "02_the_details", "01_first.md"), []byte(`Text without heading`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "02_the_details", "02_second.md"), []byte(`## Second`), 0644))
+
+	pres := &Presentation{Name: "Handout", RevealConfig: DefaultRevealConfig()}
+	out, err := RenderHandout(pres, slideDir)
+	require.NoError(t, err)
+	html := string(out)
+
+	assert.Contains(t, html, `<a href="#01_intro">Welcome</a>`)
+	assert.Contains(t, html, `<a href="#02_the_details">the details</a>`)
+	assert.Contains(t, html, `<a href="#02_the_details-01_first">02_the_details-01_first</a>`)
+	assert.Contains(t, html, `<a href="#02_the_details-02_second">Second</a>`)
+	assert.Contains(t, html, `<section class="chapter" id="02_the_details">`)
+	assert.Contains(t, html, `<article class="handout-slide" id="02_the_details-02_second">`)
+	assert.Contains(t, html, `<div class="slide-number">3</div>`)
+	assert.Contains(t, html, `<p>Greet the <em>audience</em></p>`)
+	assert.Regexp(t, `<h2 class="chapter-title">the details</h2>\s*<div class="notes">\s*<p>Explain the <em>details</em></p>`, html,
+		"Chapter notes follow the title of the chapter")
+	assert.NotContains(t, html, "<script")
+}
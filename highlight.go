@@ -0,0 +1,177 @@
+package showandtell
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// DefaultHighlightStyle is the chroma style used without a highlight config
+var DefaultHighlightStyle = "monokai"
+
+// HighlightConfig configures the syntax highlighting of code blocks. Code is
+// highlighted when rendering the slides, so it looks the same in the
+// presentation, the single file and the handout.
+type HighlightConfig struct {
+	// Style is the name of a chroma style, e.g. monokai, github or dracula
+	Style string `yaml:"style"`
+	// LineNumbers shows line numbers in all code blocks, not only in those
+	// highlighting lines
+	LineNumbers bool `yaml:"line_numbers"`
+}
+
+// highlightCSS styles the highlighted code besides the colors of the style.
+// Every step of a code block is a fragment shown on top of the previous one.
+var highlightCSS = `
+pre.chroma {
+	position: relative;
+}
+pre.chroma code {
+	background: inherit;
+}
+.reveal pre.chroma code.fragment {
+	position: absolute;
+	top: 0;
+	left: 0;
+	width: 100%;
+	box-sizing: border-box;
+}
+`
+
+func (p *Presentation) highlightConfig() *HighlightConfig {
+	if p.Highlight == nil {
+		return &HighlightConfig{}
+	}
+	return p.Highlight
+}
+
+// highlightStyle returns the chroma style of the presentation, or nil if the
+// configured style is unknown
+func (p *Presentation) highlightStyle() *chroma.Style {
+	name := p.highlightConfig().Style
+	if name == "" {
+		name = DefaultHighlightStyle
+	}
+	return styles.Registry[strings.ToLower(name)]
+}
+
+// HighlightCSS returns the CSS of the highlight style of the presentation
+func (p *Presentation) HighlightCSS() template.CSS {
+	style := p.highlightStyle()
+	if style == nil {
+		style = styles.Fallback
+	}
+	buf := &bytes.Buffer{}
+	chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true)).WriteCSS(buf, style)
+	buf.WriteString(highlightCSS)
+	return template.CSS(buf.String())
+}
+
+// fenceInfoRegexp matches the info string of fenced code like reveal.js
+// Markdown does, e.g. "go [1-3|5]"
+var fenceInfoRegexp = regexp.MustCompile(`^\s*([^\s\[]*)\s*(?:\[([^\]]*)\])?\s*$`)
+
+// highlightFence highlights fenced code. Line highlight steps in brackets
+// after the language turn on line numbers, "[]" only shows line numbers.
+func highlightFence(pres *Presentation, info, src string) (template.HTML, error) {
+	m := fenceInfoRegexp.FindStringSubmatch(info)
+	if m == nil {
+		fields := strings.Fields(info)
+		m = []string{info, fields[0], ""}
+	}
+	lineNumbers := strings.Contains(info, "[")
+	return highlightCode(pres, src, m[1], m[2], 1, lineNumbers)
+}
+
+// highlightCode renders src as highlighted HTML. steps are the lines
+// highlighted one after another, e.g. "1-3|5,7", and refer to the line
+// numbers shown, which start at firstLine.
+func highlightCode(pres *Presentation, src, lang, steps string, firstLine int, lineNumbers bool) (template.HTML, error) {
+	lineSteps, err := parseLineSteps(steps)
+	if err != nil {
+		return "", err
+	}
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+	tokens, err := chroma.Tokenise(lexer, nil, src)
+	if err != nil {
+		return "", err
+	}
+	if len(lineSteps) == 0 {
+		lineSteps = [][][2]int{nil}
+	}
+
+	codeClass := ""
+	if lang != "" {
+		codeClass = "language-" + html.EscapeString(lang)
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString(`<pre class="chroma">`)
+	for i, lines := range lineSteps {
+		formatter := chromahtml.New(
+			chromahtml.WithClasses(true),
+			chromahtml.PreventSurroundingPre(true),
+			chromahtml.WithLineNumbers(lineNumbers || pres.highlightConfig().LineNumbers),
+			chromahtml.BaseLineNumber(firstLine),
+			chromahtml.HighlightLines(lines),
+		)
+		class := codeClass
+		if i > 0 {
+			class = strings.TrimSpace(class + " fragment")
+		}
+		if class != "" {
+			fmt.Fprintf(buf, `<code class="%s">`, class)
+		} else {
+			buf.WriteString("<code>")
+		}
+		if err := formatter.Format(buf, styles.Fallback, chroma.Literator(tokens...)); err != nil {
+			return "", err
+		}
+		buf.WriteString("</code>")
+	}
+	buf.WriteString("</pre>\n")
+	return template.HTML(buf.String()), nil
+}
+
+// parseLineSteps parses line highlight steps separated by "|". Every step
+// is a comma separated list of lines and line ranges like "1-3,5".
+func parseLineSteps(steps string) ([][][2]int, error) {
+	if strings.TrimSpace(steps) == "" {
+		return nil, nil
+	}
+	var parsed [][][2]int
+	for _, step := range strings.Split(steps, "|") {
+		var ranges [][2]int
+		for _, r := range strings.Split(step, ",") {
+			parts := strings.SplitN(r, "-", 2)
+			start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+			if err != nil {
+				return nil, fmt.Errorf("Invalid line highlight %q", steps)
+			}
+			end := start
+			if len(parts) == 2 {
+				if end, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+					return nil, fmt.Errorf("Invalid line highlight %q", steps)
+				}
+			}
+			if start < 1 || end < start {
+				return nil, fmt.Errorf("Invalid line highlight %q", steps)
+			}
+			ranges = append(ranges, [2]int{start, end})
+		}
+		parsed = append(parsed, ranges)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,66 @@
+package showandtell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlightFencedCode(t *testing.T) {
+	ctx := &SlideContext{}
+	content, err := (&MarkdownSlideParser{}).ParseSlide(ctx, []byte("```go [1|2-3]\na := 1\nb := 2\nc := 3\n```\n"))
+	require.NoError(t, err)
+
+	out := string(content)
+	assert.True(t, strings.HasPrefix(out, `<pre class="chroma"><code class="language-go"><span class="line hl"><span class="ln">1</span>`), out)
+	assert.Contains(t, out, `</code><code class="language-go fragment"><span class="line"><span class="ln">1</span>`)
+	assert.Contains(t, out, `<span class="line hl"><span class="ln">3</span>`)
+	assert.Equal(t, 2, strings.Count(out, "<code"))
+
+	nodes, err := parseHTMLFragment(out)
+	require.NoError(t, err)
+	assert.Equal(t, "a := 1\nb := 2\nc := 3\n", codeText(nodes[0]))
+
+	content, err = (&MarkdownSlideParser{}).ParseSlide(ctx, []byte("```python []\nx = 1\n```\n"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<span class="line"><span class="ln">1</span>`)
+	assert.NotContains(t, string(content), `hl`)
+
+	content, err = (&MarkdownSlideParser{}).ParseSlide(ctx, []byte("```\n<b>plain</b>\n```\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `<pre class="chroma"><code><span class="line"><span class="cl">&lt;b&gt;plain&lt;/b&gt;`+"\n</span></span></code></pre>\n", string(content))
+
+	_, err = (&MarkdownSlideParser{}).ParseSlide(ctx, []byte("```go [3-1]\na := 1\n```\n"))
+	assert.EqualError(t, err, `Invalid line highlight "3-1"`)
+}
+
+func TestHighlightConfig(t *testing.T) {
+	ctx := &SlideContext{Presentation: Presentation{Highlight: &HighlightConfig{LineNumbers: true}}}
+	content, err := (&MarkdownSlideParser{}).ParseSlide(ctx, []byte("```go\na := 1\n```\n"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<span class="ln">1</span>`)
+
+	pres := &Presentation{}
+	assert.Contains(t, string(pres.HighlightCSS()), ".chroma { color: #f8f8f2; background-color: #272822; }")
+	pres.Highlight = &HighlightConfig{Style: "GitHub"}
+	assert.Contains(t, string(pres.HighlightCSS()), ".chroma { background-color: #ffffff; }")
+	assert.Contains(t, string(pres.HighlightCSS()), "code.fragment")
+
+	pres.Highlight.Style = "does-not-exist"
+	assert.Nil(t, pres.highlightStyle())
+}
+
+func TestParseLineSteps(t *testing.T) {
+	steps, err := parseLineSteps("1-3|5,7|")
+	assert.Error(t, err)
+
+	steps, err = parseLineSteps(" 1-3 | 5,7 ")
+	require.NoError(t, err)
+	assert.Equal(t, [][][2]int{{{1, 3}}, {{5, 5}, {7, 7}}}, steps)
+
+	steps, err = parseLineSteps("")
+	require.NoError(t, err)
+	assert.Nil(t, steps)
+}
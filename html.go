@@ -2,6 +2,10 @@ package showandtell
 
 import (
 	"html/template"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
 type HTMLSlideParser struct{}
@@ -11,5 +15,37 @@ func init() {
 }
 
 func (h *HTMLSlideParser) ParseSlide(ctx *SlideContext, input []byte) (content template.HTML, err error) {
-	return template.HTML(input), nil
+	out, err := renderHTMLMath(input)
+	return template.HTML(out), err
+}
+
+// parseHTMLFragment parses the HTML content of a slide
+func parseHTMLFragment(content string) ([]*html.Node, error) {
+	return html.ParseFragment(strings.NewReader(content), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+}
+
+// attr returns the value of an attribute of n, or "" if it isn't set
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent returns the text of n and all its descendants
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	buf := &strings.Builder{}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		buf.WriteString(textContent(child))
+	}
+	return buf.String()
 }
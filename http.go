@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -30,6 +31,9 @@ func messageBusClientF(ctx context.Context,
 	logger.Debug("Handling client connection")
 	defer cancel()
 
+	// Writes happen from the subscription handlers of several topics, but
+	// the websocket connection only supports one concurrent writer
+	writeLock := &sync.Mutex{}
 	subscriptionHandler := func(topic string) func(json.RawMessage) {
 		return func(value json.RawMessage) {
 			msg := &WebSocketBusMessage{
@@ -37,6 +41,8 @@ func messageBusClientF(ctx context.Context,
 				Topic: topic,
 				Type:  "message",
 			}
+			writeLock.Lock()
+			defer writeLock.Unlock()
 			if err := ws.WriteJSON(msg); err != nil {
 				logger.WithError(err).Error("Failed to write to client, closing connection")
 				ws.Close()
@@ -45,7 +51,20 @@ func messageBusClientF(ctx context.Context,
 		}
 	}
 
+	// The handlers need to be kept, as the bus identifies subscriptions by
+	// the exact handler function
+	subscriptions := map[string]func(json.RawMessage){}
+	subscriptionLock := &sync.Mutex{}
+	defer func() {
+		subscriptionLock.Lock()
+		defer subscriptionLock.Unlock()
+		for topic, handler := range subscriptions {
+			messageBus.Unsubscribe(topic, handler)
+		}
+	}()
+
 	go func() {
+		defer cancel()
 		for {
 			msg := &WebSocketBusMessage{}
 			err := ws.ReadJSON(msg)
@@ -59,11 +78,21 @@ func messageBusClientF(ctx context.Context,
 			switch msg.Type {
 			case "subscribe":
 				logger.Debug("Subscribing client")
-				messageBus.Subscribe(msg.Topic, subscriptionHandler(msg.Topic))
+				subscriptionLock.Lock()
+				if _, exists := subscriptions[msg.Topic]; !exists {
+					handler := subscriptionHandler(msg.Topic)
+					subscriptions[msg.Topic] = handler
+					messageBus.Subscribe(msg.Topic, handler)
+				}
+				subscriptionLock.Unlock()
 			case "unsubscribe":
 				logger.Debug("Unsubscribing client")
-				// FIXME, this won't likely work.
-				messageBus.Unsubscribe(msg.Topic, subscriptionHandler)
+				subscriptionLock.Lock()
+				if handler, exists := subscriptions[msg.Topic]; exists {
+					delete(subscriptions, msg.Topic)
+					messageBus.Unsubscribe(msg.Topic, handler)
+				}
+				subscriptionLock.Unlock()
 			case "publish":
 				logger.Debug("Publishing message")
 				messageBus.Publish(msg.Topic, msg.Value)
@@ -90,6 +119,7 @@ type PresentationServer struct {
 	ctx             context.Context
 	httpServer      *http.Server
 	indexBytes      []byte
+	presenterBytes  []byte
 	wsUpgrader      websocket.Upgrader
 	livereloadConns []*websocket.Conn
 	centralBus      bus.MessageBus
@@ -123,6 +153,7 @@ func NewPresentationServer(ctx context.Context, pres *Presentation, slideDir, ad
 	mux.Handle("/", http.HandlerFunc(p.serveIndex))
 	mux.Handle("/livereload", http.HandlerFunc(p.livereloadHandler))
 	mux.Handle("/messagebus", http.HandlerFunc(p.messagebusHandler))
+	mux.Handle("/presenter", http.HandlerFunc(p.servePresenter))
 	server.Handler = mux
 
 	return p, nil
@@ -134,6 +165,12 @@ func (p *PresentationServer) serveIndex(w http.ResponseWriter, r *http.Request)
 	w.Write(p.indexBytes)
 }
 
+func (p *PresentationServer) servePresenter(w http.ResponseWriter, r *http.Request) {
+	p.indexLock.Lock()
+	defer p.indexLock.Unlock()
+	w.Write(p.presenterBytes)
+}
+
 func (p *PresentationServer) livereloadHandler(w http.ResponseWriter, r *http.Request) {
 	logger := p.logger.WithFields(logrus.Fields{
 		"remoteAddr": r.RemoteAddr,
@@ -182,9 +219,24 @@ func ping(ctx context.Context, cancel context.CancelFunc, ws *websocket.Conn) {
 	}
 }
 
+// SetPresentation replaces the served presentation. Call Rerender afterwards
+// to update what is served. The profile selected for the previous
+// presentation is kept if pres has none.
+func (p *PresentationServer) SetPresentation(pres *Presentation) {
+	p.indexLock.Lock()
+	defer p.indexLock.Unlock()
+	if pres.Selection == nil && p.pres != nil {
+		pres.Selection = p.pres.Selection
+	}
+	p.pres = pres
+}
+
 func (p *PresentationServer) Rerender() (err error) {
 	p.indexLock.Lock()
 	p.indexBytes, err = RenderIndex(p.pres, p.slideDir)
+	if err == nil {
+		p.presenterBytes, err = RenderPresenter(p.pres)
+	}
 	p.indexLock.Unlock()
 	go func() {
 		for _, ws := range p.livereloadConns {
@@ -208,8 +260,15 @@ func (p *PresentationServer) Close() error {
 	return p.httpServer.Shutdown(ctx)
 }
 
-func (p *PresentationServer) Run() {
+// Run starts serving the presentation in the background. The listener is
+// opened before Run returns, so clients can connect right away.
+func (p *PresentationServer) Run() error {
+	listener, err := net.Listen("tcp", p.httpServer.Addr)
+	if err != nil {
+		return err
+	}
 	go func() {
-		p.httpServer.ListenAndServe()
+		p.httpServer.Serve(listener)
 	}()
+	return nil
 }
@@ -3,6 +3,8 @@ package showandtell
 import (
 	"context"
 	"encoding/json"
+	"io/ioutil"
+	"net/http"
 	"testing"
 	"time"
 
@@ -16,9 +18,9 @@ func TestWebSocketBus(t *testing.T) {
 	defer cancel()
 	pres := &Presentation{}
 	serverAddr := "127.0.0.1:45369"
-	server, err := NewPresentationServer(ctx, pres, "./test_project/slides", serverAddr)
+	server, err := NewPresentationServer(ctx, pres, "./test_slides", serverAddr)
 	require.NoError(t, err)
-	server.Run()
+	require.NoError(t, server.Run())
 	defer server.Close()
 
 	doneSubChan := make(chan bool, 1)
@@ -59,3 +61,52 @@ func TestWebSocketBus(t *testing.T) {
 	}
 
 }
+
+func TestPresenterView(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pres := &Presentation{Name: "Presenter Test"}
+	serverAddr := "127.0.0.1:45370"
+	server, err := NewPresentationServer(ctx, pres, "./test_slides", serverAddr)
+	require.NoError(t, err)
+	require.NoError(t, server.Run())
+	defer server.Close()
+
+	resp, err := http.Get("http://" + serverAddr + "/presenter")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "<title>Presenter Test - Presenter</title>")
+	assert.Contains(t, string(body), "/presenter/state")
+}
+
+func TestWebSocketBusUnsubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pres := &Presentation{}
+	serverAddr := "127.0.0.1:45371"
+	server, err := NewPresentationServer(ctx, pres, "./test_slides", serverAddr)
+	require.NoError(t, err)
+	require.NoError(t, server.Run())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+serverAddr+"/messagebus", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(WebSocketBusMessage{Type: "subscribe", Topic: "/presenter/state"}))
+	require.NoError(t, conn.WriteJSON(WebSocketBusMessage{Type: "unsubscribe", Topic: "/presenter/state"}))
+	require.NoError(t, conn.WriteJSON(WebSocketBusMessage{Type: "subscribe", Topic: "/presenter/sync"}))
+	// Give the message bus a little bit of time for its asynchronous operation
+	time.Sleep(time.Millisecond * 50)
+	server.centralBus.Publish("/presenter/state", json.RawMessage(`{"indexh":1}`))
+	server.centralBus.Publish("/presenter/sync", json.RawMessage(`{}`))
+
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	msg := &WebSocketBusMessage{}
+	require.NoError(t, conn.ReadJSON(msg))
+	assert.Equal(t, "/presenter/sync", msg.Topic)
+}
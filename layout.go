@@ -0,0 +1,179 @@
+package showandtell
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultLayoutDir is where layouts are loaded from, relative to the
+// presentation config, if the presentation doesn't specify a layout_dir.
+var DefaultLayoutDir = "layouts"
+
+// Layouts wrap the content of a slide which selects them with the layout key in
+// its front matter. They are executed with the SlideContext of the slide, so
+// they have access to the rendered .Content, the slide's .Params and the
+// presentation. Templates in the layout dir of a presentation can override
+// these or add new ones.
+var titleLayoutTmpl = `
+[[ define "title" ]]
+<div class="layout layout-title">
+[[ if .Content ]]
+[[ .Content ]]
+[[ else ]]
+<h1>[[ .Name ]]</h1>
+[[ with .Description ]]<p>[[ . ]]</p>[[ end ]]
+[[ end ]]
+</div>
+[[ end ]]
+`
+
+var twoColumnLayoutTmpl = `
+[[ define "two-column" ]]
+<div class="layout layout-two-column">
+[[ range columns .Content ]]
+<div class="column">
+[[ . ]]
+</div>
+[[ end ]]
+</div>
+[[ end ]]
+`
+
+var imageLeftLayoutTmpl = `
+[[ define "image-left" ]]
+<div class="layout layout-image-left">
+<div class="image">
+[[ with .Params.image ]]<img src="[[ . ]]" alt="[[ $.Params.alt ]]">[[ end ]]
+</div>
+<div class="content">
+[[ .Content ]]
+</div>
+</div>
+[[ end ]]
+`
+
+var quoteLayoutTmpl = `
+[[ define "quote" ]]
+<div class="layout layout-quote">
+<blockquote>
+[[ .Content ]]
+</blockquote>
+[[ with .Params.author ]]<p class="author">&mdash; [[ . ]]</p>[[ end ]]
+</div>
+[[ end ]]
+`
+
+var layoutCSS = `
+.reveal .layout-two-column {
+	display: flex;
+}
+.reveal .layout-two-column > .column {
+	flex: 1;
+	padding: 0 0.5em;
+}
+.reveal .layout-image-left {
+	display: flex;
+	align-items: center;
+}
+.reveal .layout-image-left > .image,
+.reveal .layout-image-left > .content {
+	flex: 1;
+	padding: 0 0.5em;
+}
+.reveal .layout-image-left > .content {
+	text-align: left;
+}
+.reveal .layout-image-left img {
+	max-width: 100%;
+}
+.reveal .layout-quote blockquote {
+	width: 80%;
+}
+.reveal .layout-quote .author {
+	text-align: right;
+	width: 80%;
+	margin: 0 auto;
+	font-style: italic;
+}
+`
+
+var columnSeparatorRegexp = regexp.MustCompile(`<hr\s*/?>`)
+
+// columns splits HTML content on horizontal rules, e.g. "***" in Markdown
+func columns(content template.HTML) []template.HTML {
+	parts := columnSeparatorRegexp.Split(string(content), -1)
+	cols := make([]template.HTML, 0, len(parts))
+	for _, p := range parts {
+		cols = append(cols, template.HTML(strings.TrimSpace(p)))
+	}
+	return cols
+}
+
+var layoutFuncs = template.FuncMap{
+	"columns":    columns,
+	"layoutCSS":  func() template.CSS { return template.CSS(layoutCSS) },
+	"diagramCSS": func() template.CSS { return template.CSS(diagramCSS) },
+	"dataCSS":    func() template.CSS { return template.CSS(dataCSS) },
+}
+
+// layoutDir returns the directory containing the layouts of the presentation
+func (p *Presentation) layoutDir() string {
+	dir := p.LayoutDir
+	if dir == "" {
+		dir = DefaultLayoutDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(p.BaseDir, dir)
+}
+
+// NewRenderer returns the DefaultRenderer with the layouts of the
+// presentation loaded on top. Every file in the layout dir is parsed as a
+// template, so it can either contain [[ define ]] blocks or be a layout named
+// after the file itself.
+func NewRenderer(pres *Presentation) (*template.Template, error) {
+	tmpl := DefaultRenderer()
+	files, err := filepath.Glob(filepath.Join(pres.layoutDir(), "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		buf, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		if _, err := tmpl.New(name).Parse(string(buf)); err != nil {
+			return nil, fmt.Errorf("Failed to parse layout %s: %s", f, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// layoutProcessor renders the content of slides with the layout they selected
+type layoutProcessor struct{}
+
+func (layoutProcessor) ProcessSlide(ctx *SlideContext, content template.HTML) (template.HTML, error) {
+	if ctx.Layout == "" {
+		return content, nil
+	}
+	tmpl, err := NewRenderer(&ctx.Presentation)
+	if err != nil {
+		return "", err
+	}
+	if tmpl.Lookup(ctx.Layout) == nil {
+		return "", fmt.Errorf("Unknown layout %s in slide %s", ctx.Layout, ctx.SourceFile)
+	}
+	tmpl.Funcs(ctx.FuncMap())
+	buf := &bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(buf, ctx.Layout, ctx); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
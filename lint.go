@@ -0,0 +1,274 @@
+package showandtell
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gopkg.in/yaml.v2"
+)
+
+// Severity of a Diagnostic
+type Severity string
+
+const (
+	// SeverityError is used for problems which break rendering or the
+	// presentation itself
+	SeverityError Severity = "error"
+	// SeverityWarning is used for problems the presentation renders with,
+	// but probably not as intended
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found by Lint. Line is 0 if the problem can't be
+// attributed to a line.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+}
+
+var validTransitions = map[string]bool{
+	"none": true, "fade": true, "slide": true, "convex": true, "concave": true, "zoom": true,
+}
+
+var validTransitionSpeeds = map[string]bool{
+	"default": true, "fast": true, "slow": true,
+}
+
+// Lint checks the presentation and all slides in slideDir and returns every
+// problem found instead of stopping at the first one. Besides everything
+// which fails rendering, like unknown keys in the front matter, it reports
+// unknown themes, invalid transitions, images which can't be found and slides
+// sharing a section ID. Problems of the presentation config itself are
+// returned by ParsePresentation as ConfigErrors.
+func Lint(pres *Presentation, slideDir string) []Diagnostic {
+	l := &linter{
+		pres:       pres,
+		slideDir:   slideDir,
+		sectionIDs: map[string]string{},
+	}
+	l.lintPresentation()
+	l.lintFolder(slideDir, pres.Outline, "", nil)
+	return l.diagnostics
+}
+
+type linter struct {
+	pres        *Presentation
+	slideDir    string
+	diagnostics []Diagnostic
+	// sectionIDs maps the section IDs to the file using it first
+	sectionIDs map[string]string
+}
+
+func (l *linter) report(file string, line int, severity Severity, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportError reports err, expanding config errors into their problems
+func (l *linter) reportError(file string, err error) {
+	switch err := err.(type) {
+	case ConfigErrors:
+		for _, configErr := range err {
+			l.diagnostics = append(l.diagnostics, configErr.Diagnostic())
+		}
+	case *ConfigError:
+		l.diagnostics = append(l.diagnostics, err.Diagnostic())
+	default:
+		l.report(file, 0, SeverityError, "%s", err)
+	}
+}
+
+func (l *linter) lintPresentation() {
+	file := l.pres.ConfigFile
+
+	for _, theme := range l.pres.Theme {
+		if _, err := FindAsset("css/theme/" + theme + ".css"); err != nil {
+			l.report(file, 0, SeverityError, "Unknown theme %s", theme)
+		}
+	}
+	if h := l.pres.Highlight; h != nil && h.Style != "" && l.pres.highlightStyle() == nil {
+		l.report(file, 0, SeverityError, "Unknown highlight style %s", h.Style)
+	}
+	if rc := l.pres.RevealConfig; rc != nil {
+		if rc.Transition != nil && !validTransition(*rc.Transition) {
+			l.report(file, 0, SeverityError, "Invalid transition %q", *rc.Transition)
+		}
+		if rc.TransitionSpeed != nil && !validTransitionSpeeds[*rc.TransitionSpeed] {
+			l.report(file, 0, SeverityError, "Invalid transition speed %q", *rc.TransitionSpeed)
+		}
+	}
+}
+
+// validTransition accepts a transition like "fade" or separate transitions
+// for entering and leaving a slide like "slide-in fade-out".
+func validTransition(transition string) bool {
+	parts := strings.Fields(transition)
+	if len(parts) == 0 || len(parts) > 2 {
+		return false
+	}
+	for _, p := range parts {
+		if len(parts) == 2 {
+			if !strings.HasSuffix(p, "-in") && !strings.HasSuffix(p, "-out") {
+				return false
+			}
+		}
+		p = strings.TrimSuffix(strings.TrimSuffix(p, "-in"), "-out")
+		if !validTransitions[p] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *linter) lintFolder(folder string, outline []*OutlineEntry, idPrefix string, chapter *Slide) {
+	entries, err := listSlideEntries(folder, outline)
+	if err != nil {
+		file := folder
+		if len(outline) > 0 && l.pres.ConfigFile != "" {
+			file = l.pres.ConfigFile
+		}
+		l.report(file, 0, SeverityError, "%s", err)
+		return
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if seen[e.path] {
+			// The outline may use a slide more than once on purpose
+			continue
+		}
+		seen[e.path] = true
+
+		id := idPrefix + generateSectionID(e.path)
+		if other, exists := l.sectionIDs[id]; exists {
+			l.report(e.path, 0, SeverityWarning, "Section ID %s is already used by %s, links to it are ambiguous", id, other)
+		} else {
+			l.sectionIDs[id] = e.path
+		}
+
+		if e.isDir {
+			l.lintChapter(e.path, e.outline, id+"-", chapter)
+		} else {
+			l.lintSlide(e.path, chapter)
+		}
+	}
+}
+
+// lintChapter checks the config file of a chapter and its slides
+func (l *linter) lintChapter(dir string, outline []*OutlineEntry, idPrefix string, parent *Slide) {
+	chapter, err := loadChapter(dir, parent)
+	if err != nil {
+		l.reportError(filepath.Join(dir, ChapterConfigFile), err)
+		return
+	}
+	if chapter.SourceFile != "" {
+		if buf, err := ioutil.ReadFile(chapter.SourceFile); err == nil {
+			l.lintImages(chapter.SourceFile, buf, chapter, parent)
+		}
+	}
+	l.lintFolder(dir, outline, idPrefix, chapter)
+}
+
+func (l *linter) lintSlide(slidePath string, chapter *Slide) {
+	buf, err := ioutil.ReadFile(slidePath)
+	if err != nil {
+		l.report(slidePath, 0, SeverityError, "%s", err)
+		return
+	}
+
+	l.lintFrontMatter(slidePath, buf)
+
+	pipeline := DefaultSlidePipeline()
+	pipeline.Defaults = chapter
+	slides, err := pipeline.RunAll(l.pres, slidePath)
+	if err != nil {
+		l.reportError(slidePath, err)
+		return
+	}
+
+	for _, s := range flattenSlides(slides) {
+		l.lintImages(slidePath, buf, s, chapter)
+	}
+}
+
+// lintFrontMatter warns about YAML front matter between +++, which is only
+// read as YAML because it fails to decode as TOML
+func (l *linter) lintFrontMatter(slidePath string, src []byte) {
+	if l.pres.LegacyFrontMatter || !bytes.HasPrefix(src, frontMatterDelimiter) ||
+		frontMatterFormat(src, l.pres) != frontMatterYAML {
+		return
+	}
+	fm, _ := parseFrontMatter(src)
+	var v map[string]interface{}
+	if yaml.Unmarshal(fm, &v) == nil {
+		l.report(slidePath, 1, SeverityWarning,
+			"Front matter between +++ is YAML instead of TOML, use --- as delimiter or set legacy_front_matter")
+	}
+}
+
+// lintImages checks that all images of a slide can be found in the custom
+// files or relative to the slide folder. A background image inherited from
+// the chapter is reported for the chapter only.
+func (l *linter) lintImages(slidePath string, src []byte, s *Slide, chapter *Slide) {
+	if bg := backgroundImage(s); bg != "" && bg != backgroundImage(chapter) {
+		l.lintImage(slidePath, src, bg)
+	}
+	nodes, err := parseHTMLFragment(string(s.Content))
+	if err != nil {
+		return
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.DataAtom == atom.Img {
+			l.lintImage(slidePath, src, attr(n, "src"))
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+}
+
+func (l *linter) lintImage(slidePath string, src []byte, imgSrc string) {
+	if imgSrc == "" || isRemoteRef(imgSrc) {
+		return
+	}
+	if _, err := (&assetInliner{slideFolder: l.slideDir}).findImage(imgSrc); err != nil {
+		l.report(slidePath, textLine(src, imgSrc), SeverityError, "Image %s not found", imgSrc)
+	}
+}
+
+func backgroundImage(s *Slide) string {
+	if s == nil || s.Background == nil {
+		return ""
+	}
+	return s.Background.Image
+}
+
+// textLine returns the first line containing text, or 0
+func textLine(src []byte, text string) int {
+	idx := bytes.Index(src, []byte(text))
+	if idx < 0 {
+		return 0
+	}
+	return bytes.Count(src[:idx], []byte("\n")) + 1
+}
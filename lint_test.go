@@ -0,0 +1,94 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	require.NoError(t, cssBox.AddString("theme/lint.css", `.lint {}`))
+	require.NoError(t, imagesBox.AddString("lint.png", "\x89PNG\r\n\x1a\n"))
+
+	dir, err := ioutil.TempDir("", "sat-lint")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	slideDir := filepath.Join(dir, "slides")
+	require.NoError(t, os.MkdirAll(filepath.Join(slideDir, "03_chapter"), 0755))
+
+	configFile := filepath.Join(dir, "presentation.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`name: Lint
+theme: [lint, does-not-exist]
+`), 0644))
+	files := map[string]string{
+		"01_ok.md":   "+++\ntransition: slide-in fade-out\n+++\n![ok](images/lint.png)\n",
+		"02_typo.md": "+++\nnotes: typo\ntransistion: fade\n+++\n",
+		"02_bad.md":  "+++\nnotes: bad\ntransition: wobble\n+++\n",
+		"02_image.md": `---
+background:
+  image: images/missing-background.png
+---
+# Image
+
+![missing](images/missing.png)
+`,
+		"03_chapter.md":         "# Same ID as the chapter",
+		"03_chapter/01_tmpl.md": "[[ .DoesNotExist ]]",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, name), []byte(content), 0644))
+	}
+
+	pres, err := ParsePresentation(configFile)
+	require.NoError(t, err)
+	diagnostics := Lint(pres, slideDir)
+
+	bad := filepath.Join(slideDir, "02_bad.md")
+	assert.Contains(t, diagnostics, Diagnostic{configFile, 0, SeverityError, "Unknown theme does-not-exist"})
+	assert.Contains(t, diagnostics, Diagnostic{filepath.Join(slideDir, "02_typo.md"), 3, SeverityError, "Unknown key transistion"})
+	assert.Contains(t, diagnostics, Diagnostic{bad, 3, SeverityError,
+		`Invalid transition "wobble", valid are none, fade, slide, convex, concave and zoom`})
+	image := filepath.Join(slideDir, "02_image.md")
+	assert.Contains(t, diagnostics, Diagnostic{image, 3, SeverityError, "Image images/missing-background.png not found"})
+	assert.Contains(t, diagnostics, Diagnostic{image, 7, SeverityError, "Image images/missing.png not found"})
+	assert.Contains(t, diagnostics, Diagnostic{filepath.Join(slideDir, "03_chapter.md"), 0, SeverityWarning,
+		"Section ID 03_chapter is already used by " + filepath.Join(slideDir, "03_chapter") + ", links to it are ambiguous"})
+
+	legacy := Diagnostic{filepath.Join(slideDir, "01_ok.md"), 1, SeverityWarning,
+		"Front matter between +++ is YAML instead of TOML, use --- as delimiter or set legacy_front_matter"}
+	assert.Contains(t, diagnostics, legacy)
+
+	var tmplErrors int
+	for _, d := range diagnostics {
+		if d != legacy {
+			assert.NotEqual(t, filepath.Join(slideDir, "01_ok.md"), d.File, d.String())
+		}
+		if d.File == filepath.Join(slideDir, "03_chapter", "01_tmpl.md") {
+			tmplErrors++
+		}
+	}
+	assert.Equal(t, 1, tmplErrors)
+	// 01_ok.md, 02_typo.md and 02_bad.md use YAML between +++
+	assert.Len(t, diagnostics, 10)
+
+	pres.LegacyFrontMatter = true
+	assert.NotContains(t, Lint(pres, slideDir), legacy)
+}
+
+func TestValidTransition(t *testing.T) {
+	for transition, valid := range map[string]bool{
+		"fade":              true,
+		"slide-in fade-out": true,
+		"fade-in":           true,
+		"fade-in fade":      false,
+		"fade slide":        false,
+		"wobble":            false,
+		"":                  false,
+	} {
+		assert.Equal(t, valid, validTransition(transition), transition)
+	}
+}
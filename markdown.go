@@ -1,7 +1,12 @@
 package showandtell
 
 import (
+	"bytes"
+	"fmt"
 	"html/template"
+	"io"
+	"regexp"
+	"strings"
 
 	blackfriday "gopkg.in/russross/blackfriday.v2"
 )
@@ -14,13 +19,212 @@ const mardownExtensions = blackfriday.NoIntraEmphasis | blackfriday.Tables | bla
 	blackfriday.Strikethrough | blackfriday.SpaceHeadings | blackfriday.HeadingIDs |
 	blackfriday.BackslashLineBreak | blackfriday.DefinitionLists
 
+// Default separators of SlideSeparators, as used by reveal.js for external
+// Markdown
+var (
+	DefaultHorizontalSeparator = `^---$`
+	DefaultVerticalSeparator   = `^--$`
+	DefaultNotesSeparator      = `^Note:`
+)
+
+// SlideSeparators configure splitting Markdown files into multiple slides.
+// Each is a regular expression matched against single lines, empty values
+// use the defaults. Every slide can start with its own front matter. Lines in
+// fenced code blocks never separate slides.
+type SlideSeparators struct {
+	// Horizontal starts the next slide
+	Horizontal string `yaml:"horizontal"`
+	// Vertical starts the next slide in a vertical stack
+	Vertical string `yaml:"vertical"`
+	// Notes starts the speaker notes of the current slide, the rest of the
+	// line after the match is part of the notes
+	Notes string `yaml:"notes"`
+}
+
+func compileSeparator(expr, defaultExpr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		expr = defaultExpr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid slide separator %q: %s", expr, err)
+	}
+	return re, nil
+}
+
 type MarkdownSlideParser struct{}
 
 func (m *MarkdownSlideParser) ParseSlide(ctx *SlideContext, input []byte) (content template.HTML, err error) {
-	out := blackfriday.Run(input,
-		blackfriday.WithExtensions(
-			mardownExtensions,
-		),
+	input, maths, err := protectMath(input)
+	if err != nil {
+		return "", err
+	}
+	renderer := &markdownRenderer{
+		HTMLRenderer: blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+			Flags: blackfriday.CommonHTMLFlags,
+		}),
+		pres: &ctx.Presentation,
+	}
+	// The IDs of headings are set by attribute blocks, which blackfriday
+	// would take as ID as a whole
+	root := blackfriday.New(blackfriday.WithExtensions(mardownExtensions &^ blackfriday.HeadingIDs)).Parse(braceFenceInfo(input))
+	renderer.attributes = markdownAttributes(root, ctx.Fragments)
+	out := &bytes.Buffer{}
+	renderer.RenderHeader(out, root)
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(out, node, entering)
+	})
+	renderer.RenderFooter(out, root)
+	return template.HTML(restoreMath(out.String(), maths)), renderer.err
+}
+
+var (
+	fenceRegexp      = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	fenceStepsRegexp = regexp.MustCompile("^( {0,3})(```+|~~~+)[ \t]*([^\\s{\\[]*)[ \t]*(\\[[^\\]]*\\])[ \t]*$")
+)
+
+// braceFenceInfo rewrites fenced code starting like reveal.js Markdown with
+// "```go [1-3|5]" to "```{go [1-3|5]}", as blackfriday only supports info
+// strings with spaces in braces.
+func braceFenceInfo(input []byte) []byte {
+	lines := bytes.Split(input, []byte("\n"))
+	openFence := ""
+	for i, line := range lines {
+		m := fenceRegexp.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if openFence != "" {
+			if bytes.HasPrefix(m[1], []byte(openFence)) && len(bytes.TrimSpace(line[len(m[0]):])) == 0 {
+				openFence = ""
+			}
+			continue
+		}
+		openFence = string(m[1])
+		if m := fenceStepsRegexp.FindSubmatch(line); m != nil {
+			lines[i] = []byte(fmt.Sprintf("%s%s{%s %s}", m[1], m[2], m[3], m[4]))
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// markdownRenderer highlights fenced code blocks and renders those in the
+// language of a DiagramRenderer as diagram. Elements with attributes, see
+// markdownAttributes, get them added to their opening tag.
+type markdownRenderer struct {
+	*blackfriday.HTMLRenderer
+	pres       *Presentation
+	attributes map[*blackfriday.Node]*htmlAttributes
+	err        error
+}
+
+func (r *markdownRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if attrs, exists := r.attributes[node]; exists && entering {
+		buf := &bytes.Buffer{}
+		status := r.HTMLRenderer.RenderNode(buf, node, entering)
+		w.Write(addAttributes(buf.Bytes(), attrs))
+		return status
+	}
+	if node.Type != blackfriday.CodeBlock {
+		return r.HTMLRenderer.RenderNode(w, node, entering)
+	}
+	var out template.HTML
+	var err error
+	isDiagram := false
+	if lang := strings.Fields(string(node.Info)); len(lang) > 0 {
+		out, isDiagram, err = renderDiagram(lang[0], string(node.Literal))
+	}
+	if !isDiagram {
+		out, err = highlightFence(r.pres, string(node.Info), string(node.Literal))
+	}
+	if err != nil {
+		r.err = err
+		return blackfriday.Terminate
+	}
+	io.WriteString(w, string(out))
+	return blackfriday.GoToNext
+}
+
+// SplitSlides splits input on the separators of the presentation. Without
+// separators configured every file is a single slide.
+func (m *MarkdownSlideParser) SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error) {
+	if pres.Separators == nil {
+		return [][]*SlideSource{{{Body: input, Line: 1}}}, nil
+	}
+	horizontal, err := compileSeparator(pres.Separators.Horizontal, DefaultHorizontalSeparator)
+	if err != nil {
+		return nil, err
+	}
+	vertical, err := compileSeparator(pres.Separators.Vertical, DefaultVerticalSeparator)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := compileSeparator(pres.Separators.Notes, DefaultNotesSeparator)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stacks [][]*SlideSource
+		stack  []*SlideSource
+
+		current        = &SlideSource{Line: 1}
+		body, notesBuf = &bytes.Buffer{}, &bytes.Buffer{}
+		atStart        = true
+		inNotes        bool
+		// The delimiter of the front matter or fence the line is in
+		frontMatterEnd, fenceEnd string
 	)
-	return template.HTML(out), nil
+	next := func(line int) {
+		current.Body = append([]byte{}, body.Bytes()...)
+		current.Notes = append([]byte{}, notesBuf.Bytes()...)
+		stack = append(stack, current)
+		current = &SlideSource{Line: line}
+		body.Reset()
+		notesBuf.Reset()
+		atStart, inNotes = true, false
+	}
+
+	for i, line := range strings.SplitAfter(string(input), "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case frontMatterEnd != "":
+			if trimmed == frontMatterEnd {
+				frontMatterEnd = ""
+			}
+		case fenceEnd != "":
+			if strings.HasPrefix(strings.TrimSpace(trimmed), fenceEnd) {
+				fenceEnd = ""
+			}
+		case atStart && strings.TrimSpace(trimmed) == "":
+			// Front matter has to be at the start of the body
+			current.Line = i + 2
+			continue
+		case atStart && (trimmed == string(frontMatterDelimiter) || trimmed == string(yamlFrontMatterDelimiter)):
+			frontMatterEnd = trimmed
+		case strings.HasPrefix(strings.TrimSpace(trimmed), "```"):
+			fenceEnd = "```"
+		case strings.HasPrefix(strings.TrimSpace(trimmed), "~~~"):
+			fenceEnd = "~~~"
+		case horizontal.MatchString(trimmed):
+			next(i + 2)
+			stacks = append(stacks, stack)
+			stack = nil
+			continue
+		case vertical.MatchString(trimmed):
+			next(i + 2)
+			continue
+		case !inNotes && notes.MatchString(trimmed):
+			inNotes = true
+			line = line[notes.FindStringIndex(trimmed)[1]:]
+		}
+		atStart = false
+		if inNotes {
+			notesBuf.WriteString(line)
+		} else {
+			body.WriteString(line)
+		}
+	}
+	next(0)
+	return append(stacks, stack), nil
 }
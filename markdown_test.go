@@ -0,0 +1,120 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var talk = `---
+transition: zoom
+---
+# Title
+
+---
+
+## Stack 1
+--
+---
+notes: From front matter
+---
+## Stack 2
+
+` + "```" + `
+---
+` + "```" + `
+Note:
+Some *notes*
+---
+## Last
+`
+
+func TestSplitMarkdownSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-split")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "01_talk.md"), []byte(talk), 0644))
+
+	// Without separators the file is a single slide
+	slides, err := ParseSlides(&Presentation{}, dir)
+	require.NoError(t, err)
+	require.Len(t, slides, 1)
+	assert.Equal(t, "01_talk", slides[0].SectionID)
+
+	slides, err = ParseSlides(&Presentation{Separators: &SlideSeparators{}}, dir)
+	require.NoError(t, err)
+	require.Len(t, slides, 3)
+
+	assert.Equal(t, "01_talk-1", slides[0].SectionID)
+	require.NotNil(t, slides[0].Transition)
+	assert.Equal(t, "zoom", *slides[0].Transition)
+	assert.Equal(t, "<h1>Title</h1>\n", string(slides[0].Content))
+
+	stack := slides[1]
+	assert.Equal(t, "01_talk-2", stack.SectionID)
+	require.Len(t, stack.SubSlides, 2)
+	assert.Equal(t, "01_talk-2-1", stack.SubSlides[0].SectionID)
+	assert.Equal(t, "<h2>Stack 1</h2>\n", string(stack.SubSlides[0].Content))
+	assert.Equal(t, "01_talk-2-2", stack.SubSlides[1].SectionID)
+	assert.Contains(t, string(stack.SubSlides[1].Content), `<pre class="chroma"><code><span class="line"><span class="cl">---`)
+	assert.Equal(t, "<p>From front matter</p>\n<p>Some <em>notes</em></p>\n", string(stack.SubSlides[1].Notes))
+
+	assert.Equal(t, "01_talk-3", slides[2].SectionID)
+	assert.Equal(t, "<h2>Last</h2>\n", string(slides[2].Content))
+}
+
+func TestSplitMarkdownSlidesCustomSeparators(t *testing.T) {
+	pres := &Presentation{Separators: &SlideSeparators{
+		Horizontal: `^\*\*\*$`,
+		Vertical:   `^___$`,
+		Notes:      `^Notes?: *`,
+	}}
+	stacks, err := (&MarkdownSlideParser{}).SplitSlides(pres, "", []byte("one\n***\ntwo\n___\nthree\nNotes: x\n"))
+	require.NoError(t, err)
+	require.Len(t, stacks, 2)
+	require.Len(t, stacks[1], 2)
+	assert.Equal(t, "one\n", string(stacks[0][0].Body))
+	assert.Equal(t, 3, stacks[1][0].Line)
+	assert.Equal(t, "three\n", string(stacks[1][1].Body))
+	assert.Equal(t, "x\n", string(stacks[1][1].Notes))
+
+	pres.Separators.Horizontal = "("
+	_, err = (&MarkdownSlideParser{}).SplitSlides(pres, "", []byte("one"))
+	assert.Error(t, err)
+}
+
+func TestSplitMarkdownSlidesErrorLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-split")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "talk.md"),
+		[]byte("# One\n---\n\n---\ntransistion: zoom\n---\n# Two\n"), 0644))
+
+	_, err = ParseSlides(&Presentation{Separators: &SlideSeparators{}}, dir)
+	require.IsType(t, ConfigErrors{}, err)
+	assert.Equal(t, 5, err.(ConfigErrors)[0].Line)
+}
+
+func TestSplitMarkdownSlidesInChapter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-split")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "01_ch"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "01_ch", "01_talk.md"), []byte("# A\n--\n# B\n---\n# C\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "01_ch", "02_doc.adoc"), []byte("== D\n\n=== E\n"), 0644))
+
+	pres := &Presentation{Separators: &SlideSeparators{}, RevealConfig: DefaultRevealConfig()}
+	out, err := RenderIndex(pres, dir)
+	require.NoError(t, err)
+	html := string(out)
+	for _, id := range []string{"01_ch-01_talk-1-1", "01_ch-01_talk-1-2", "01_ch-01_talk-2"} {
+		assert.Contains(t, html, `id="`+id+`"`)
+	}
+	assert.Regexp(t, `(?s)id="01_ch".*id="01_ch-01_talk-1-1".*<h1>A</h1>.*id="01_ch-01_talk-1-2".*<h1>B</h1>.*<h1>C</h1>`, html,
+		"Stacks of files in chapters are slides of the chapter")
+	assert.Regexp(t, `(?s)id="01_ch-02_doc-1-1".*<h2>D</h2>.*id="01_ch-02_doc-1-2".*<h3>E</h3>`, html)
+}
@@ -0,0 +1,305 @@
+package showandtell
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Math in slides is written in TeX between $ and $ inline or between $$ and
+// $$ displayed as block, and rendered to MathML, which browsers display
+// without scripts or fonts to download. A $ can be escaped as \$. Inline math
+// must not start or end with a space, and the closing $ must not be followed
+// by a digit, so prices like $5 and $10 stay text.
+//
+// The supported TeX is a subset of LaTeX math: letters, numbers and
+// operators, ^ and _, \frac, \sqrt, \left and \right, \text, font commands
+// like \mathbf and \mathbb, accents, greek letters and common symbols, large
+// operators and the matrix, cases and aligned environments.
+
+// mathCache keeps rendered math by the hash of its TeX
+var mathCache = &renderCache{}
+
+// renderMath renders TeX math to MathML
+func renderMath(tex string, display bool) (template.HTML, error) {
+	mode := "inline"
+	if display {
+		mode = "display"
+	}
+	return mathCache.get(contentHash("math", mode, tex), func() (template.HTML, error) {
+		p := &mathParser{src: tex, display: display}
+		if err := p.tokenize(); err != nil {
+			return "", fmt.Errorf("Failed to render math %q: %s", tex, err)
+		}
+		row, err := p.parseRow()
+		if err == nil && p.pos < len(p.tokens) {
+			err = fmt.Errorf("Unexpected %s", p.tokens[p.pos].text)
+		}
+		if err != nil {
+			return "", fmt.Errorf("Failed to render math %q: %s", tex, err)
+		}
+		attrs := ""
+		if display {
+			attrs = ` display="block"`
+		}
+		return template.HTML(fmt.Sprintf(`<math xmlns="http://www.w3.org/1998/Math/MathML"%s><semantics>%s`+
+			`<annotation encoding="application/x-tex">%s</annotation></semantics></math>`,
+			attrs, row, html.EscapeString(strings.TrimSpace(tex)))), nil
+	})
+}
+
+// replaceMath replaces the math in text by the result of replace. Code spans
+// in backticks are skipped if codeSpans is set.
+func replaceMath(text string, codeSpans bool, replace func(tex string, display bool) (string, error)) (string, error) {
+	out := &strings.Builder{}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '$':
+			out.WriteByte('$')
+			i += 2
+			continue
+		case c == '`' && codeSpans:
+			// Code spans end with a run of as many backticks as they start with
+			n := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			end := -1
+			for j := i + n; j < len(text); {
+				if text[j] != '`' {
+					j++
+					continue
+				}
+				k := j
+				for k < len(text) && text[k] == '`' {
+					k++
+				}
+				if k-j == n {
+					end = k
+					break
+				}
+				j = k
+			}
+			if end < 0 {
+				end = i + n
+			}
+			out.WriteString(text[i:end])
+			i = end
+			continue
+		case strings.HasPrefix(text[i:], "$$"):
+			if end := strings.Index(text[i+2:], "$$"); end > 0 {
+				r, err := replace(text[i+2:i+2+end], true)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(r)
+				i += end + 4
+				continue
+			}
+		case c == '$':
+			if end := inlineMathEnd(text, i, codeSpans); end > 0 {
+				r, err := replace(text[i+1:end], false)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(r)
+				i = end + 1
+				continue
+			}
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String(), nil
+}
+
+// inlineMathEnd returns the index of the $ closing the inline math starting
+// at start, or -1. Math never contains code spans.
+func inlineMathEnd(text string, start int, codeSpans bool) int {
+	if start+1 >= len(text) || isSpace(text[start+1]) {
+		return -1
+	}
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			if codeSpans {
+				return -1
+			}
+		case '\n':
+			// Math doesn't span paragraphs
+			rest := text[i+1:]
+			if j := strings.IndexByte(rest, '\n'); j >= 0 && strings.TrimSpace(rest[:j]) == "" {
+				return -1
+			}
+		case '$':
+			if isSpace(text[i-1]) || (i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9') {
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// mathPlaceholder protects rendered math from Markdown processing
+func mathPlaceholder(i int) string {
+	return fmt.Sprintf("SATMATH%dX", i)
+}
+
+// protectedMath is math replaced by a placeholder
+type protectedMath struct {
+	// source is the TeX including its delimiters
+	source string
+	html   template.HTML
+}
+
+// protectMath replaces the math in the Markdown input, outside of fenced code
+// and code spans, by placeholders. restoreMath replaces the placeholders in
+// the rendered HTML by the MathML.
+func protectMath(input []byte) ([]byte, []protectedMath, error) {
+	if !strings.Contains(string(input), "$") {
+		return input, nil, nil
+	}
+	var (
+		maths     []protectedMath
+		out       = &strings.Builder{}
+		text      = &strings.Builder{}
+		openFence string
+	)
+	flush := func() error {
+		replaced, err := replaceMath(text.String(), true, func(tex string, display bool) (string, error) {
+			math, err := renderMath(tex, display)
+			if err != nil {
+				return "", err
+			}
+			source := "$" + tex + "$"
+			if display {
+				source = "$" + source + "$"
+			}
+			maths = append(maths, protectedMath{source: source, html: math})
+			return mathPlaceholder(len(maths) - 1), nil
+		})
+		out.WriteString(replaced)
+		text.Reset()
+		return err
+	}
+	for _, line := range strings.SplitAfter(string(input), "\n") {
+		m := fenceRegexp.FindStringSubmatch(line)
+		switch {
+		case openFence != "":
+			if m != nil && strings.HasPrefix(m[1], openFence) && strings.TrimSpace(line[len(m[0]):]) == "" {
+				openFence = ""
+			}
+			out.WriteString(line)
+		case m != nil:
+			if err := flush(); err != nil {
+				return nil, nil, err
+			}
+			openFence = m[1]
+			out.WriteString(line)
+		default:
+			text.WriteString(line)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, nil, err
+	}
+	return []byte(out.String()), maths, nil
+}
+
+// restoreMath replaces the placeholders in the text of content by the
+// MathML. Placeholders in tags, e.g. in the alt text of images or in URLs,
+// are replaced by the escaped TeX, as attributes can't contain markup, and
+// so are those in code like indented code blocks.
+func restoreMath(content string, maths []protectedMath) string {
+	if len(maths) == 0 {
+		return content
+	}
+	blocks := make([]string, 0, 2*len(maths))
+	text := make([]string, 0, 2*len(maths))
+	tags := make([]string, 0, 2*len(maths))
+	for i, m := range maths {
+		placeholder := mathPlaceholder(i)
+		blocks = append(blocks, "<p>"+placeholder+"</p>", string(m.html))
+		text = append(text, placeholder, string(m.html))
+		tags = append(tags, placeholder, html.EscapeString(m.source))
+	}
+	content = strings.NewReplacer(blocks...).Replace(content)
+	textReplacer, tagReplacer := strings.NewReplacer(text...), strings.NewReplacer(tags...)
+
+	out := &strings.Builder{}
+	z := html.NewTokenizer(strings.NewReader(content))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return out.String()
+		case html.StartTagToken:
+			if name, _ := z.TagName(); mathSkipElements[string(name)] {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); mathSkipElements[string(name)] && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				textReplacer.WriteString(out, string(z.Raw()))
+				continue
+			}
+		}
+		tagReplacer.WriteString(out, string(z.Raw()))
+	}
+}
+
+// mathSkipElements are the HTML elements whose text is never math
+var mathSkipElements = map[string]bool{
+	"pre": true, "code": true, "kbd": true, "samp": true, "script": true, "style": true, "textarea": true, "math": true, "svg": true,
+}
+
+// renderHTMLMath renders the math in the text of HTML content
+func renderHTMLMath(input []byte) ([]byte, error) {
+	if !strings.Contains(string(input), "$") {
+		return input, nil
+	}
+	out := &strings.Builder{}
+	z := html.NewTokenizer(strings.NewReader(string(input)))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return nil, z.Err()
+			}
+			return []byte(out.String()), nil
+		case html.StartTagToken:
+			if name, _ := z.TagName(); mathSkipElements[string(name)] {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); mathSkipElements[string(name)] && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				replaced, err := replaceMath(string(z.Raw()), false, func(tex string, display bool) (string, error) {
+					math, err := renderMath(html.UnescapeString(tex), display)
+					return string(math), err
+				})
+				if err != nil {
+					return nil, err
+				}
+				out.WriteString(replaced)
+				continue
+			}
+		}
+		out.Write(z.Raw())
+	}
+}
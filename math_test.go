@@ -0,0 +1,99 @@
+package showandtell
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownMath(t *testing.T) {
+	content, err := (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte(
+		"# Sum of $a_1$ and $b_1$\n\nCosts $5 and $10, `$x$` and \\$y\\$\n\n$$\n\\sum_{i=1}^n i\n$$\n\n```\n$z$\n```\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `<h1>Sum of <math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><msub><mi>a</mi><mn>1</mn></msub>`+
+		`<annotation encoding="application/x-tex">a_1</annotation></semantics></math> and `+
+		`<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><msub><mi>b</mi><mn>1</mn></msub>`+
+		`<annotation encoding="application/x-tex">b_1</annotation></semantics></math></h1>
+
+<p>Costs $5 and $10, <code>$x$</code> and $y$</p>
+
+<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics>`+
+		`<mrow><munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`+
+		`<annotation encoding="application/x-tex">\sum_{i=1}^n i</annotation></semantics></math>
+<pre class="chroma"><code><span class="line"><span class="cl">$z$
+</span></span></code></pre>
+`, string(content))
+
+	content, err = (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte(
+		"![cost $a<b$](x.png) [l](http://e/$y$) <span title=\"$a$\">$a$</span>\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `<p><img src="x.png" alt="cost $a&lt;b$" /> <a href="http://e/$y$">l</a> <span title="$a$">`+
+		`<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics><mi>a</mi>`+
+		`<annotation encoding="application/x-tex">a</annotation></semantics></math></span></p>
+`, string(content), "Math in attributes stays TeX")
+
+	content, err = (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte(
+		"Shell\n\n    echo $HOME $USER$\n\n<pre>$a$</pre>\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "<math")
+	assert.Contains(t, string(content), "echo $HOME $USER$")
+	assert.Contains(t, string(content), "<pre>$a$</pre>")
+
+	_, err = (&MarkdownSlideParser{}).ParseSlide(&SlideContext{}, []byte("Broken $\\frac{a}$\n"))
+	assert.EqualError(t, err, `Failed to render math "\\frac{a}": Missing argument`)
+}
+
+func TestHTMLMath(t *testing.T) {
+	content, err := (&HTMLSlideParser{}).ParseSlide(&SlideContext{}, []byte(
+		`<p class="$">$a &lt; b$</p><pre>$x$</pre><script>var s = "$b$"</script>`))
+	require.NoError(t, err)
+	assert.Equal(t, `<p class="$"><math xmlns="http://www.w3.org/1998/Math/MathML"><semantics>`+
+		`<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow><annotation encoding="application/x-tex">a &lt; b</annotation>`+
+		`</semantics></math></p><pre>$x$</pre><script>var s = "$b$"</script>`, string(content))
+
+	nodes, err := parseHTMLFragment(string(content))
+	require.NoError(t, err)
+	assert.Equal(t, "a<b", mathText(nodes[0].FirstChild), "PowerPoint gets the text without the TeX")
+}
+
+func TestRenderMath(t *testing.T) {
+	mathML := func(tex string, display bool) string {
+		out, err := renderMath(tex, display)
+		require.NoError(t, err, tex)
+		s := strings.TrimPrefix(string(out), `<math xmlns="http://www.w3.org/1998/Math/MathML"><semantics>`)
+		s = strings.TrimPrefix(s, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><semantics>`)
+		return s[:strings.Index(s, "<annotation")]
+	}
+	for tex, expected := range map[string]string{
+		`x^2 - y_i'`:             `<mrow><msup><mi>x</mi><mn>2</mn></msup><mo>−</mo><msubsup><mi>y</mi><mi>i</mi><mo>′</mo></msubsup></mrow>`,
+		`\frac{1}{2}\sqrt[3]x`:   `<mrow><mfrac><mn>1</mn><mn>2</mn></mfrac><mroot><mi>x</mi><mn>3</mn></mroot></mrow>`,
+		`\sum_i`:                 `<msub><mo>∑</mo><mi>i</mi></msub>`,
+		`\mathbb{R}^n`:           `<msup><mi>ℝ</mi><mi>n</mi></msup>`,
+		`\mathbf{v}\cdot\vec{w}`: `<mrow><mi>𝐯</mi><mo>⋅</mo><mover accent="true"><mi>w</mi><mo stretchy="false">→</mo></mover></mrow>`,
+		`\text{if } x \leq 0`:    `<mrow><mtext>if </mtext><mi>x</mi><mo>≤</mo><mn>0</mn></mrow>`,
+		`\left\{ x \right.`:      `<mrow><mo fence="true" form="prefix" stretchy="true">{</mo><mi>x</mi></mrow>`,
+		`\begin{pmatrix} 1 & 0 \\ 0 & 1 \\ \end{pmatrix}`: `<mrow><mo fence="true" form="prefix" stretchy="true">(</mo>` +
+			`<mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>0</mn></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mn>1</mn></mtd></mtr></mtable>` +
+			`<mo fence="true" form="postfix" stretchy="true">)</mo></mrow>`,
+	} {
+		assert.Equal(t, expected, mathML(tex, false), tex)
+	}
+	assert.Equal(t, `<munder><mo form="prefix" movablelimits="true">lim</mo><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder>`,
+		mathML(`\lim_{x \to 0}`, true))
+
+	for tex, expected := range map[string]string{
+		`\foo`:                            `Unknown math command \foo`,
+		`{a`:                              `Missing }`,
+		`a}`:                              `Unexpected }`,
+		`x^2^3`:                           `Double superscript`,
+		`\left( x`:                        `Missing \right`,
+		`\begin{matrix} a \end{cases}`:    `\begin{matrix} ended by \end{cases}`,
+		`\begin{tabular} a \end{tabular}`: `Unknown math environment tabular`,
+	} {
+		_, err := renderMath(tex, false)
+		assert.EqualError(t, err, fmt.Sprintf("Failed to render math %q: %s", tex, expected), tex)
+	}
+}
@@ -0,0 +1,623 @@
+package showandtell
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// mathParser converts TeX math to MathML
+type mathParser struct {
+	src     string
+	tokens  []mathToken
+	pos     int
+	display bool
+	// variant is the mathvariant of letters set by font commands like \mathbf
+	variant string
+}
+
+type mathToken struct {
+	text string
+	// pos is the offset of the token in the source
+	pos int
+}
+
+func (p *mathParser) tokenize() error {
+	for i := 0; i < len(p.src); {
+		r, size := utf8.DecodeRuneInString(p.src[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '\\':
+			i++
+			if i >= len(p.src) {
+				return fmt.Errorf("Missing command after \\")
+			}
+			if isASCIILetter(p.src[i]) {
+				for i < len(p.src) && isASCIILetter(p.src[i]) {
+					i++
+				}
+			} else {
+				_, size := utf8.DecodeRuneInString(p.src[i:])
+				i += size
+			}
+		case r >= '0' && r <= '9':
+			for i < len(p.src) && (isDigit(p.src[i]) || p.src[i] == '.' && i+1 < len(p.src) && isDigit(p.src[i+1])) {
+				i++
+			}
+		default:
+			i += size
+		}
+		p.tokens = append(p.tokens, mathToken{text: p.src[start:i], pos: start})
+	}
+	return nil
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (p *mathParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *mathParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *mathParser) expect(text string) error {
+	if t := p.peek(); t != text {
+		if t == "" {
+			return fmt.Errorf("Missing %s", text)
+		}
+		return fmt.Errorf("Expected %s, found %s", text, t)
+	}
+	p.pos++
+	return nil
+}
+
+// parseRow parses atoms up to the end of the input or one of the stop
+// tokens, which isn't consumed
+func (p *mathParser) parseRow(stop ...string) (string, error) {
+	var items []string
+loop:
+	for p.pos < len(p.tokens) {
+		t := p.peek()
+		for _, s := range stop {
+			if t == s {
+				break loop
+			}
+		}
+		switch t {
+		case "}", `\right`, `\end`, "&", `\\`:
+			return "", fmt.Errorf("Unexpected %s", t)
+		}
+		item, err := p.parseScripted()
+		if err != nil {
+			return "", err
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return mrow(items), nil
+}
+
+func mrow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+// parseScripted parses an atom with its sub- and superscripts
+func (p *mathParser) parseScripted() (string, error) {
+	base, limits, err := p.parseAtom()
+	if err != nil || base == "" {
+		return base, err
+	}
+	var sub, sup string
+	var primes []string
+	for {
+		t := p.peek()
+		if t == "'" {
+			p.pos++
+			primes = append(primes, "<mo>′</mo>")
+			continue
+		}
+		if t != "^" && t != "_" {
+			break
+		}
+		p.pos++
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+		if t == "_" {
+			if sub != "" {
+				return "", fmt.Errorf("Double subscript")
+			}
+			sub = arg
+		} else {
+			if sup != "" {
+				return "", fmt.Errorf("Double superscript")
+			}
+			sup = arg
+		}
+	}
+	if len(primes) > 0 {
+		if sup != "" {
+			primes = append(primes, sup)
+		}
+		sup = mrow(primes)
+	}
+	return scripts(base, sub, sup, limits && p.display), nil
+}
+
+func scripts(base, sub, sup string, limits bool) string {
+	under, over, both := "msub", "msup", "msubsup"
+	if limits {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case sub != "" && sup != "":
+		return "<" + both + ">" + base + sub + sup + "</" + both + ">"
+	case sub != "":
+		return "<" + under + ">" + base + sub + "</" + under + ">"
+	case sup != "":
+		return "<" + over + ">" + base + sup + "</" + over + ">"
+	}
+	return base
+}
+
+// parseArgument parses a group in braces or a single atom
+func (p *mathParser) parseArgument() (string, error) {
+	switch p.peek() {
+	case "", "}", "&", `\\`:
+		return "", fmt.Errorf("Missing argument")
+	case "{":
+		p.pos++
+		row, err := p.parseRow("}")
+		if err != nil {
+			return "", err
+		}
+		return row, p.expect("}")
+	}
+	arg, _, err := p.parseAtom()
+	return arg, err
+}
+
+// parseRawArgument returns the source of a group in braces
+func (p *mathParser) parseRawArgument() (string, error) {
+	if p.peek() != "{" {
+		return "", fmt.Errorf("Expected { after %s", p.tokens[p.pos-1].text)
+	}
+	start := p.tokens[p.pos].pos + 1
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		switch p.tokens[p.pos].text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				end := p.tokens[p.pos].pos
+				p.pos++
+				return p.src[start:end], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("Missing }")
+}
+
+// parseAtom parses a single atom, limits is true for large operators whose
+// scripts are placed below and above in display math
+func (p *mathParser) parseAtom() (out string, limits bool, err error) {
+	t := p.next()
+	switch {
+	case t == "{":
+		p.pos--
+		out, err = p.parseArgument()
+		return out, false, err
+	case t == "^" || t == "_":
+		// Scripts without base
+		p.pos--
+		return "<mrow></mrow>", false, nil
+	case t == "~":
+		return `<mspace width="0.25em"></mspace>`, false, nil
+	case isDigit(t[0]):
+		return "<mn>" + p.styled(t) + "</mn>", false, nil
+	case t[0] == '\\' && len(t) > 1:
+		return p.parseCommand(t)
+	}
+	r, _ := utf8.DecodeRuneInString(t)
+	if unicode.IsLetter(r) {
+		return p.identifier(t), false, nil
+	}
+	if op, exists := mathCharOperators[t]; exists {
+		t = op
+	}
+	return "<mo>" + html.EscapeString(t) + "</mo>", false, nil
+}
+
+func (p *mathParser) identifier(text string) string {
+	if p.variant == "normal" && utf8.RuneCountInString(text) == 1 {
+		return `<mi mathvariant="normal">` + html.EscapeString(text) + "</mi>"
+	}
+	return "<mi>" + p.styled(text) + "</mi>"
+}
+
+// styled maps letters and digits to the mathematical alphanumeric symbols of
+// the current variant, as browsers only support mathvariant="normal"
+func (p *mathParser) styled(text string) string {
+	alphabet, exists := mathAlphabets[p.variant]
+	if !exists {
+		return html.EscapeString(text)
+	}
+	b := &strings.Builder{}
+	for _, r := range text {
+		switch {
+		case alphabet.exceptions[r] != 0:
+			r = alphabet.exceptions[r]
+		case r >= 'A' && r <= 'Z':
+			r = alphabet.upper + r - 'A'
+		case r >= 'a' && r <= 'z' && alphabet.lower != 0:
+			r = alphabet.lower + r - 'a'
+		case r >= '0' && r <= '9' && alphabet.digits != 0:
+			r = alphabet.digits + r - '0'
+		}
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	return b.String()
+}
+
+func (p *mathParser) parseCommand(cmd string) (string, bool, error) {
+	name := cmd[1:]
+	if ident, exists := mathIdentifiers[name]; exists {
+		return "<mi>" + ident + "</mi>", false, nil
+	}
+	if op, exists := mathOperators[name]; exists {
+		return "<mo>" + html.EscapeString(op) + "</mo>", false, nil
+	}
+	if op, exists := mathLargeOperators[name]; exists {
+		return "<mo>" + op + "</mo>", name != "int" && name != "iint" && name != "iiint" && name != "oint", nil
+	}
+	if mathFunctions[name] {
+		return "<mi>" + name + "</mi>", false, nil
+	}
+	if mathLimitFunctions[name] {
+		return `<mo form="prefix" movablelimits="true">` + name + "</mo>", true, nil
+	}
+	if width, exists := mathSpaces[name]; exists {
+		return `<mspace width="` + width + `"></mspace>`, false, nil
+	}
+	if accent, exists := mathAccents[name]; exists {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "underline" {
+			return `<munder accentunder="true">` + arg + `<mo stretchy="true">` + accent + "</mo></munder>", false, nil
+		}
+		return `<mover accent="true">` + arg + `<mo stretchy="` + fmt.Sprint(strings.HasPrefix(name, "wide") || name == "overline") + `">` + accent + "</mo></mover>", false, nil
+	}
+	if variant, exists := mathVariants[name]; exists {
+		outer := p.variant
+		p.variant = variant
+		arg, err := p.parseArgument()
+		p.variant = outer
+		return arg, false, err
+	}
+
+	switch name {
+	case "displaystyle", "textstyle", "limits", "nolimits":
+		return "", false, nil
+	case "frac", "dfrac", "tfrac", "binom":
+		num, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		denom, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "binom" {
+			return `<mrow><mo>(</mo><mfrac linethickness="0">` + num + denom + `</mfrac><mo>)</mo></mrow>`, false, nil
+		}
+		return "<mfrac>" + num + denom + "</mfrac>", false, nil
+	case "sqrt":
+		index := ""
+		if p.peek() == "[" {
+			p.pos++
+			row, err := p.parseRow("]")
+			if err != nil {
+				return "", false, err
+			}
+			if err := p.expect("]"); err != nil {
+				return "", false, err
+			}
+			index = row
+		}
+		arg, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		if index != "" {
+			return "<mroot>" + arg + index + "</mroot>", false, nil
+		}
+		return "<msqrt>" + arg + "</msqrt>", false, nil
+	case "overset", "underset", "stackrel":
+		script, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		base, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "underset" {
+			return "<munder>" + base + script + "</munder>", false, nil
+		}
+		return "<mover>" + base + script + "</mover>", false, nil
+	case "text", "textrm", "textit", "textbf", "mbox", "operatorname":
+		text, err := p.parseRawArgument()
+		if err != nil {
+			return "", false, err
+		}
+		switch name {
+		case "operatorname":
+			if utf8.RuneCountInString(text) == 1 {
+				return `<mi mathvariant="normal">` + html.EscapeString(text) + "</mi>", false, nil
+			}
+			return "<mi>" + html.EscapeString(text) + "</mi>", false, nil
+		case "textbf", "textit":
+			outer := p.variant
+			p.variant = map[string]string{"textbf": "bold", "textit": "italic"}[name]
+			text = p.styled(text)
+			p.variant = outer
+		default:
+			text = html.EscapeString(text)
+		}
+		return "<mtext>" + text + "</mtext>", false, nil
+	case "left":
+		open, err := p.delimiter()
+		if err != nil {
+			return "", false, err
+		}
+		row, err := p.parseRow(`\right`)
+		if err != nil {
+			return "", false, err
+		}
+		if err := p.expect(`\right`); err != nil {
+			return "", false, err
+		}
+		closing, err := p.delimiter()
+		if err != nil {
+			return "", false, err
+		}
+		return "<mrow>" + fence(open, "prefix") + row + fence(closing, "postfix") + "</mrow>", false, nil
+	case "begin":
+		return p.parseEnvironment()
+	}
+	return "", false, fmt.Errorf("Unknown math command %s", cmd)
+}
+
+// delimiter parses the delimiter after \left and \right, "." is none
+func (p *mathParser) delimiter() (string, error) {
+	t := p.next()
+	switch {
+	case t == "":
+		return "", fmt.Errorf("Missing delimiter")
+	case t == ".":
+		return "", nil
+	case t[0] == '\\' && len(t) > 1:
+		if op, exists := mathOperators[t[1:]]; exists {
+			return op, nil
+		}
+		return "", fmt.Errorf("Invalid delimiter %s", t)
+	}
+	return t, nil
+}
+
+func fence(delimiter, form string) string {
+	if delimiter == "" {
+		return ""
+	}
+	return `<mo fence="true" form="` + form + `" stretchy="true">` + html.EscapeString(delimiter) + "</mo>"
+}
+
+// mathEnvironment describes the delimiters and column alignment of a matrix
+// like environment
+type mathEnvironment struct {
+	open, close  string
+	columnalign  string
+	displaystyle bool
+}
+
+var mathEnvironments = map[string]mathEnvironment{
+	"matrix":   {},
+	"pmatrix":  {open: "(", close: ")"},
+	"bmatrix":  {open: "[", close: "]"},
+	"Bmatrix":  {open: "{", close: "}"},
+	"vmatrix":  {open: "|", close: "|"},
+	"Vmatrix":  {open: "‖", close: "‖"},
+	"cases":    {open: "{", columnalign: "left left"},
+	"aligned":  {columnalign: "right left", displaystyle: true},
+	"align":    {columnalign: "right left", displaystyle: true},
+	"align*":   {columnalign: "right left", displaystyle: true},
+	"gathered": {displaystyle: true},
+	"split":    {columnalign: "right left", displaystyle: true},
+}
+
+func (p *mathParser) parseEnvironment() (string, bool, error) {
+	name, err := p.parseRawArgument()
+	if err != nil {
+		return "", false, err
+	}
+	env, exists := mathEnvironments[name]
+	if !exists {
+		return "", false, fmt.Errorf("Unknown math environment %s", name)
+	}
+
+	var rows [][]string
+	row := []string{}
+	for {
+		cell, err := p.parseRow("&", `\\`, `\end`)
+		if err != nil {
+			return "", false, err
+		}
+		row = append(row, cell)
+		t := p.next()
+		if t == "&" {
+			continue
+		}
+		if t != `\\` && t != `\end` {
+			return "", false, fmt.Errorf("Missing \\end{%s}", name)
+		}
+		if !(t == `\end` && len(row) == 1 && row[0] == "<mrow></mrow>") {
+			rows = append(rows, row)
+		}
+		row = []string{}
+		if t == `\end` {
+			break
+		}
+	}
+	end, err := p.parseRawArgument()
+	if err != nil {
+		return "", false, err
+	}
+	if end != name {
+		return "", false, fmt.Errorf("\\begin{%s} ended by \\end{%s}", name, end)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("<mtable")
+	if env.columnalign != "" {
+		fmt.Fprintf(b, ` columnalign="%s"`, env.columnalign)
+	}
+	if env.displaystyle {
+		b.WriteString(` displaystyle="true"`)
+	}
+	b.WriteString(">")
+	for _, row := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range row {
+			b.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>")
+	if env.open == "" && env.close == "" {
+		return b.String(), false, nil
+	}
+	return "<mrow>" + fence(env.open, "prefix") + b.String() + fence(env.close, "postfix") + "</mrow>", false, nil
+}
+
+// mathCharOperators replaces ASCII operators by their proper characters
+var mathCharOperators = map[string]string{
+	"-": "−",
+	"*": "∗",
+}
+
+var mathIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "ell": "ℓ", "hbar": "ℏ", "emptyset": "∅",
+	"varnothing": "∅", "aleph": "ℵ", "Re": "ℜ", "Im": "ℑ",
+}
+
+var mathOperators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "oplus": "⊕", "otimes": "⊗",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "ll": "≪", "gg": "≫",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺",
+	"mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃",
+	"supseteq": "⊇", "cup": "∪", "cap": "∩", "setminus": "∖",
+	"forall": "∀", "exists": "∃", "neg": "¬", "lnot": "¬", "land": "∧", "wedge": "∧",
+	"lor": "∨", "vee": "∨", "perp": "⊥", "parallel": "∥", "mid": "∣",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"lbrace": "{", "rbrace": "}", "vert": "|", "Vert": "‖", "|": "‖",
+	"{": "{", "}": "}", "$": "$", "%": "%", "#": "#", "&": "&", "_": "_",
+}
+
+var mathLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂", "bigoplus": "⨁",
+	"bigotimes": "⨂", "int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+}
+
+var mathFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+	"log": true, "ln": true, "lg": true, "exp": true, "det": true, "dim": true, "ker": true,
+	"deg": true, "arg": true, "gcd": true, "Pr": true,
+}
+
+// mathLimitFunctions are functions with limits below them in display math
+var mathLimitFunctions = map[string]bool{
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true, "sup": true,
+	"inf": true, "argmax": true, "argmin": true,
+}
+
+var mathSpaces = map[string]string{
+	",": "0.167em", ":": "0.222em", ">": "0.222em", ";": "0.278em", "!": "-0.167em",
+	" ": "0.25em", "quad": "1em", "qquad": "2em",
+}
+
+var mathAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "¯", "vec": "→", "tilde": "~",
+	"widetilde": "~", "dot": "˙", "ddot": "¨", "underline": "_",
+}
+
+var mathVariants = map[string]string{
+	"mathbf": "bold", "mathit": "italic", "mathrm": "normal", "mathbb": "double-struck",
+	"mathcal": "script", "mathfrak": "fraktur", "mathsf": "sans-serif", "mathtt": "monospace",
+	"boldsymbol": "bold-italic",
+}
+
+// mathAlphabet is the start of a range of mathematical alphanumeric symbols,
+// exceptions are letters encoded outside of the range
+type mathAlphabet struct {
+	upper, lower, digits rune
+	exceptions           map[rune]rune
+}
+
+var mathAlphabets = map[string]mathAlphabet{
+	"bold":        {upper: 0x1D400, lower: 0x1D41A, digits: 0x1D7CE},
+	"italic":      {upper: 0x1D434, lower: 0x1D44E, exceptions: map[rune]rune{'h': 0x210E}},
+	"bold-italic": {upper: 0x1D468, lower: 0x1D482},
+	"script": {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 0x212C, 'E': 0x2130, 'F': 0x2131, 'H': 0x210B, 'I': 0x2110, 'L': 0x2112, 'M': 0x2133,
+		'R': 0x211B, 'e': 0x212F, 'g': 0x210A, 'o': 0x2134,
+	}},
+	"fraktur": {upper: 0x1D504, lower: 0x1D51E, exceptions: map[rune]rune{
+		'C': 0x212D, 'H': 0x210C, 'I': 0x2111, 'R': 0x211C, 'Z': 0x2128,
+	}},
+	"double-struck": {upper: 0x1D538, lower: 0x1D552, digits: 0x1D7D8, exceptions: map[rune]rune{
+		'C': 0x2102, 'H': 0x210D, 'N': 0x2115, 'P': 0x2119, 'Q': 0x211A, 'R': 0x211D, 'Z': 0x2124,
+	}},
+	"sans-serif": {upper: 0x1D5A0, lower: 0x1D5BA, digits: 0x1D7E2},
+	"monospace":  {upper: 0x1D670, lower: 0x1D68A, digits: 0x1D7F6},
+}
@@ -0,0 +1,332 @@
+package showandtell
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	RegisterSlideFormat("ipynb", &NotebookSlideParser{})
+}
+
+// NotebookSlideParser reads Jupyter notebooks. Markdown cells are rendered
+// like Markdown slides, code cells are highlighted and followed by their
+// stored outputs, notebooks are never executed.
+//
+// The slide type of the cells, set in the slideshow cell toolbar of Jupyter,
+// splits the notebook like the slideshows of Jupyter:
+//
+//	slide      starts the next slide
+//	subslide   starts the next slide in a vertical stack
+//	fragment   shows the cell one step after the previous one
+//	skip       leaves the cell out
+//	notes      adds the cell to the speaker notes
+//	-          continues the fragment or notes of the previous cell
+//
+// The "showandtell" key in the metadata of a cell starting a slide is its
+// front matter, e.g. {"showandtell": {"layout": "title"}}.
+type NotebookSlideParser struct{}
+
+type notebook struct {
+	NBFormat int             `json:"nbformat"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	Cells    []*notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType    string                             `json:"cell_type"`
+	Metadata    notebookCellMetadata               `json:"metadata"`
+	Source      notebookText                       `json:"source"`
+	Outputs     []*notebookOutput                  `json:"outputs,omitempty"`
+	Attachments map[string]map[string]notebookText `json:"attachments,omitempty"`
+}
+
+type notebookCellMetadata struct {
+	Slideshow struct {
+		SlideType string `json:"slide_type,omitempty"`
+	} `json:"slideshow"`
+	Jupyter struct {
+		SourceHidden  bool `json:"source_hidden,omitempty"`
+		OutputsHidden bool `json:"outputs_hidden,omitempty"`
+	} `json:"jupyter"`
+	RawMimetype string          `json:"raw_mimetype,omitempty"`
+	FrontMatter json.RawMessage `json:"showandtell,omitempty"`
+}
+
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"`
+	Name       string                  `json:"name,omitempty"`
+	Text       notebookText            `json:"text,omitempty"`
+	Data       map[string]notebookText `json:"data,omitempty"`
+	Traceback  []string                `json:"traceback,omitempty"`
+}
+
+// notebookText is a string, which notebooks store as a string or as a list
+// of lines
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = notebookText(s)
+	return nil
+}
+
+func parseNotebook(input []byte) (*notebook, error) {
+	nb := &notebook{}
+	if err := json.Unmarshal(input, nb); err != nil {
+		return nil, fmt.Errorf("Invalid notebook: %s", err)
+	}
+	if nb.NBFormat < 4 {
+		return nil, fmt.Errorf("Notebooks of nbformat %d are not supported, convert them to nbformat 4", nb.NBFormat)
+	}
+	return nb, nil
+}
+
+// language returns the programming language of the code cells
+func (nb *notebook) language() string {
+	var metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+	}
+	json.Unmarshal(nb.Metadata, &metadata)
+	switch {
+	case metadata.LanguageInfo.Name != "":
+		return metadata.LanguageInfo.Name
+	case metadata.Kernelspec.Language != "":
+		return metadata.Kernelspec.Language
+	}
+	return "python"
+}
+
+// SplitSlides splits a notebook by the slide types of its cells
+func (n *NotebookSlideParser) SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error) {
+	nb, err := parseNotebook(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		stacks      [][]*SlideSource
+		slide       *notebook
+		frontMatter json.RawMessage
+		notes       []string
+		previous    string
+	)
+	next := func() error {
+		if slide == nil {
+			return nil
+		}
+		body, err := json.Marshal(slide)
+		if err != nil {
+			return err
+		}
+		if len(frontMatter) == 0 {
+			frontMatter = json.RawMessage("{}")
+		}
+		src := &SlideSource{
+			Body:       append(append([]byte(frontMatter), '\n'), body...),
+			Notes:      []byte(strings.Join(notes, "\n\n")),
+			Line:       1,
+			NoTemplate: true,
+		}
+		stacks[len(stacks)-1] = append(stacks[len(stacks)-1], src)
+		slide, notes, frontMatter = nil, nil, nil
+		return nil
+	}
+	for _, cell := range nb.Cells {
+		slideType := cell.Metadata.Slideshow.SlideType
+		if slideType == "" || slideType == "-" {
+			slideType = "-"
+			if previous == "notes" || previous == "fragment" {
+				slideType = previous
+			}
+		}
+		previous = slideType
+		switch slideType {
+		case "skip":
+			continue
+		case "slide", "subslide":
+			if err := next(); err != nil {
+				return nil, err
+			}
+			if slideType == "slide" || len(stacks) == 0 {
+				stacks = append(stacks, nil)
+			}
+		}
+		if len(stacks) == 0 {
+			stacks = append(stacks, nil)
+		}
+		if slideType == "notes" {
+			if cell.CellType == "code" {
+				notes = append(notes, "```"+nb.language()+"\n"+strings.TrimSuffix(string(cell.Source), "\n")+"\n```")
+			} else {
+				notes = append(notes, string(cell.Source))
+			}
+			continue
+		}
+		if slide == nil {
+			slide = &notebook{NBFormat: nb.NBFormat, Metadata: nb.Metadata}
+			frontMatter = cell.Metadata.FrontMatter
+		}
+		slide.Cells = append(slide.Cells, cell)
+	}
+	if err := next(); err != nil {
+		return nil, err
+	}
+	if len(stacks) == 0 {
+		return [][]*SlideSource{{{Body: []byte("{}\n{\"nbformat\": 4, \"cells\": []}"), Line: 1, NoTemplate: true}}}, nil
+	}
+	return stacks, nil
+}
+
+// ParseSlide renders the cells of a notebook, cells of the type fragment and
+// those continuing them are shown one after another
+func (n *NotebookSlideParser) ParseSlide(ctx *SlideContext, input []byte) (template.HTML, error) {
+	nb, err := parseNotebook(input)
+	if err != nil {
+		return "", err
+	}
+	buf := &strings.Builder{}
+	inFragment := false
+	for _, cell := range nb.Cells {
+		switch cell.Metadata.Slideshow.SlideType {
+		case "skip", "notes":
+			continue
+		case "fragment":
+			if inFragment {
+				buf.WriteString("</div>\n")
+			}
+			buf.WriteString("<div class=\"fragment\">\n")
+			inFragment = true
+		case "slide", "subslide":
+			if inFragment {
+				buf.WriteString("</div>\n")
+			}
+			inFragment = false
+		}
+		out, err := n.renderCell(ctx, nb, cell)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(string(out))
+	}
+	if inFragment {
+		buf.WriteString("</div>\n")
+	}
+	return template.HTML(buf.String()), nil
+}
+
+var notebookAttachmentRegexp = regexp.MustCompile(`(src|href)="attachment:([^"]+)"`)
+
+func (n *NotebookSlideParser) renderCell(ctx *SlideContext, nb *notebook, cell *notebookCell) (template.HTML, error) {
+	switch cell.CellType {
+	case "markdown":
+		out, err := (&MarkdownSlideParser{}).ParseSlide(ctx, []byte(cell.Source))
+		if err != nil {
+			return "", err
+		}
+		// Images pasted into the cell are attachments
+		return template.HTML(notebookAttachmentRegexp.ReplaceAllStringFunc(string(out), func(attr string) string {
+			m := notebookAttachmentRegexp.FindStringSubmatch(attr)
+			for mimeType, data := range cell.Attachments[m[2]] {
+				return fmt.Sprintf(`%s="%s"`, m[1], notebookDataURI(mimeType, string(data)))
+			}
+			return attr
+		})), nil
+	case "raw":
+		if cell.Metadata.RawMimetype == "text/html" {
+			return template.HTML(cell.Source), nil
+		}
+		return "", nil
+	case "code":
+	default:
+		return "", nil
+	}
+
+	buf := &strings.Builder{}
+	if !cell.Metadata.Jupyter.SourceHidden && strings.TrimSpace(string(cell.Source)) != "" {
+		code, err := highlightCode(&ctx.Presentation, strings.TrimSuffix(string(cell.Source), "\n")+"\n", nb.language(), "", 1, false)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(string(code))
+	}
+	if cell.Metadata.Jupyter.OutputsHidden {
+		return template.HTML(buf.String()), nil
+	}
+	for _, output := range cell.Outputs {
+		out, err := renderNotebookOutput(ctx, output)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(string(out))
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// notebookMimeTypes are the types of rich outputs in the order of preference
+var notebookMimeTypes = []string{"text/html", "image/svg+xml", "image/png", "image/jpeg", "image/gif",
+	"text/markdown", "text/latex", "text/plain"}
+
+var ansiEscapeRegexp = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+func renderNotebookOutput(ctx *SlideContext, output *notebookOutput) (template.HTML, error) {
+	switch output.OutputType {
+	case "stream":
+		return template.HTML(fmt.Sprintf("<pre class=\"output %s\">%s</pre>\n",
+			template.HTMLEscapeString(output.Name), template.HTMLEscapeString(ansiEscapeRegexp.ReplaceAllString(string(output.Text), "")))), nil
+	case "error":
+		traceback := ansiEscapeRegexp.ReplaceAllString(strings.Join(output.Traceback, "\n"), "")
+		return template.HTML(fmt.Sprintf("<pre class=\"output error\">%s</pre>\n", template.HTMLEscapeString(traceback))), nil
+	case "execute_result", "display_data":
+	default:
+		return "", nil
+	}
+
+	for _, mimeType := range notebookMimeTypes {
+		data, exists := output.Data[mimeType]
+		if !exists {
+			continue
+		}
+		var out string
+		switch mimeType {
+		case "text/html", "image/svg+xml":
+			out = string(data)
+		case "text/markdown", "text/latex":
+			content, err := (&MarkdownSlideParser{}).ParseSlide(ctx, []byte(data))
+			if err != nil {
+				return "", err
+			}
+			out = string(content)
+		case "text/plain":
+			out = "<pre>" + template.HTMLEscapeString(ansiEscapeRegexp.ReplaceAllString(string(data), "")) + "</pre>"
+		default:
+			out = fmt.Sprintf(`<img src="%s">`, notebookDataURI(mimeType, string(data)))
+		}
+		return template.HTML("<div class=\"output\">" + out + "</div>\n"), nil
+	}
+	return "", nil
+}
+
+// notebookDataURI returns the data URI of base64 encoded data, or of SVG source
+func notebookDataURI(mimeType, data string) string {
+	if mimeType == "image/svg+xml" && strings.Contains(data, "<svg") {
+		return "data:" + mimeType + ";charset=utf-8," + strings.NewReplacer("%", "%25", "\"", "%22", "#", "%23", "\n", "%0A").Replace(data)
+	}
+	return "data:" + mimeType + ";base64," + strings.Join(strings.Fields(data), "")
+}
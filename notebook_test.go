@@ -0,0 +1,80 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {"language_info": {"name": "python"}},
+ "cells": [
+  {"cell_type": "markdown", "metadata": {"slideshow": {"slide_type": "slide"}, "showandtell": {"layout": "title"}},
+   "source": ["# Results\n", "\n", "Growth of $x_1$"]},
+  {"cell_type": "markdown", "metadata": {"slideshow": {"slide_type": "notes"}}, "source": "Say *hello*"},
+  {"cell_type": "code", "metadata": {}, "source": "import secret", "outputs": []},
+  {"cell_type": "code", "metadata": {"slideshow": {"slide_type": "skip"}}, "source": "import os", "outputs": []},
+  {"cell_type": "code", "metadata": {"slideshow": {"slide_type": "slide"}}, "execution_count": 1,
+   "source": ["df = load()\n", "print(len(df))\n", "df"],
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["3\n"]},
+    {"output_type": "execute_result", "execution_count": 1, "metadata": {},
+     "data": {"text/html": ["<table><tr><td>1</td></tr></table>"], "text/plain": ["   a\n0  1"]}}
+   ]},
+  {"cell_type": "code", "metadata": {"slideshow": {"slide_type": "fragment"}}, "source": "plot()",
+   "outputs": [
+    {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0KGgo=\n", "text/plain": "<Figure>"}},
+    {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[0;31mValueError\u001b[0m: bad"]}
+   ]},
+  {"cell_type": "markdown", "metadata": {"slideshow": {"slide_type": "-"}}, "source": "![chart](attachment:chart.png)",
+   "attachments": {"chart.png": {"image/png": "AAAA"}}},
+  {"cell_type": "markdown", "metadata": {"slideshow": {"slide_type": "subslide"}}, "source": "## Details"}
+ ]
+}`
+
+func TestNotebookSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-notebook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	nbPath := filepath.Join(dir, "analysis.ipynb")
+	require.NoError(t, ioutil.WriteFile(nbPath, []byte(testNotebook), 0644))
+
+	slides, err := DefaultSlidePipeline().RunAll(&Presentation{}, nbPath)
+	require.NoError(t, err)
+	require.Len(t, slides, 2)
+
+	title := slides[0]
+	assert.Equal(t, "analysis-1", title.SectionID)
+	assert.Equal(t, "title", title.Layout)
+	assert.Contains(t, string(title.Content), "<h1>Results</h1>")
+	assert.Contains(t, string(title.Content), "<msub><mi>x</mi><mn>1</mn></msub>")
+	assert.NotContains(t, string(title.Content), "import")
+	assert.Equal(t, "<p>Say <em>hello</em></p>\n\n<pre><code class=\"language-python\">import secret\n</code></pre>\n", string(title.Notes),
+		"Cells without slide type continue the notes")
+
+	require.Len(t, slides[1].SubSlides, 2)
+	results := string(slides[1].SubSlides[0].Content)
+	assert.Contains(t, results, `<pre class="chroma"><code class="language-python"><span class="line"><span class="cl"><span class="n">df</span>`)
+	assert.Contains(t, results, "<pre class=\"output stdout\">3\n</pre>\n<div class=\"output\"><table><tr><td>1</td></tr></table></div>\n<div class=\"fragment\">\n")
+	assert.Contains(t, results, `<div class="output"><img src="data:image/png;base64,iVBORw0KGgo="></div>`)
+	assert.Contains(t, results, "<pre class=\"output error\">ValueError: bad</pre>\n<p><img src=\"data:image/png;base64,AAAA\" alt=\"chart\" /></p>\n</div>\n")
+	assert.NotContains(t, results, "import os")
+	assert.Equal(t, "<h2>Details</h2>\n", string(slides[1].SubSlides[1].Content))
+}
+
+func TestNotebookErrors(t *testing.T) {
+	_, err := (&NotebookSlideParser{}).SplitSlides(&Presentation{}, "", []byte(`{"nbformat": 3, "worksheets": []}`))
+	assert.EqualError(t, err, "Notebooks of nbformat 3 are not supported, convert them to nbformat 4")
+	_, err = (&NotebookSlideParser{}).SplitSlides(&Presentation{}, "", []byte(`{"cells": [`))
+	assert.EqualError(t, err, "Invalid notebook: unexpected end of JSON input")
+
+	stacks, err := (&NotebookSlideParser{}).SplitSlides(&Presentation{}, "", []byte(`{"nbformat": 4, "cells": []}`))
+	require.NoError(t, err)
+	assert.Len(t, stacks, 1, "An empty notebook is an empty slide")
+}
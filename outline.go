@@ -0,0 +1,92 @@
+package showandtell
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// OutlineEntry references a slide file or chapter folder, relative to the
+// folder containing it. In presentation.yaml an entry is either just the path
+// or a mapping with the path and the slides of a chapter:
+//
+//	outline:
+//	  - title.md
+//	  - path: chapter
+//	    slides: [intro.md, details.html]
+//	  - title.md
+//
+// Slides may be referenced more than once, slides not referenced at all are
+// left out. Chapters without slides use all slides in directory order.
+type OutlineEntry struct {
+	Path   string          `yaml:"path"`
+	Slides []*OutlineEntry `yaml:"slides"`
+}
+
+func (o *OutlineEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&o.Path); err == nil {
+		return nil
+	}
+	type plain OutlineEntry
+	return unmarshal((*plain)(o))
+}
+
+// slideEntry is a slide file or chapter folder to parse, in presentation order
+type slideEntry struct {
+	path    string
+	isDir   bool
+	outline []*OutlineEntry
+}
+
+// listSlideEntries determines the slides of slideFolder. Without an outline
+// the directory order is used.
+func listSlideEntries(slideFolder string, outline []*OutlineEntry) ([]*slideEntry, error) {
+	if len(outline) == 0 {
+		files, err := ioutil.ReadDir(slideFolder)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]*slideEntry, 0, len(files))
+		for _, f := range files {
+			if f.Name() == ChapterConfigFile {
+				continue
+			}
+			entries = append(entries, &slideEntry{
+				path:  filepath.Join(slideFolder, f.Name()),
+				isDir: f.IsDir(),
+			})
+		}
+		return entries, nil
+	}
+
+	entries := make([]*slideEntry, 0, len(outline))
+	for _, o := range outline {
+		slidePath := filepath.Join(slideFolder, filepath.FromSlash(o.Path))
+		fi, err := os.Stat(slidePath)
+		if err != nil {
+			return nil, fmt.Errorf("Outline entry %s not found in %s", o.Path, slideFolder)
+		}
+		if !fi.IsDir() && len(o.Slides) > 0 {
+			return nil, fmt.Errorf("Outline entry %s lists slides, but is not a chapter folder", o.Path)
+		}
+		entries = append(entries, &slideEntry{
+			path:    slidePath,
+			isDir:   fi.IsDir(),
+			outline: o.Slides,
+		})
+	}
+	return entries, nil
+}
+
+// uniqueSectionIDs makes sure a section ID is only used once, even if the same
+// slide is included multiple times by an outline.
+type uniqueSectionIDs map[string]int
+
+func (u uniqueSectionIDs) get(id string) string {
+	u[id]++
+	if count := u[id]; count > 1 {
+		return fmt.Sprintf("%s-%d", id, count)
+	}
+	return id
+}
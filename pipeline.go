@@ -0,0 +1,271 @@
+package showandtell
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// SlideProcessor post-processes the HTML produced by a SlideParser. The
+// content passed in is also available as ctx.Content.
+type SlideProcessor interface {
+	ProcessSlide(ctx *SlideContext, content template.HTML) (template.HTML, error)
+}
+
+var slideProcessors = []SlideProcessor{}
+
+// RegisterSlideProcessor adds a processor to the post-processing stage of the
+// default pipeline. Processors run in the order they were registered.
+func RegisterSlideProcessor(processor SlideProcessor) {
+	slideProcessors = append(slideProcessors, processor)
+}
+
+// SlidePipeline turns a slide file into a Slide in explicit stages:
+//
+//  1. FrontMatter splits off the front matter and decodes it into the Slide
+//  2. ExpandTemplate executes the remaining body as template
+//  3. Parse converts the expanded body to HTML with the parser registered
+//     for the file extension
+//  4. PostProcess runs all slide processors on the HTML
+type SlidePipeline struct {
+	Parsers    map[string]SlideParser
+	Processors []SlideProcessor
+	// Defaults are the settings of the chapter, which the front matter of
+	// the slides overrides
+	Defaults *Slide
+}
+
+// DefaultSlidePipeline returns a pipeline using all registered slide formats
+// and processors, followed by applying the layout of the slide.
+func DefaultSlidePipeline() *SlidePipeline {
+	processors := make([]SlideProcessor, 0, len(slideProcessors)+1)
+	processors = append(processors, slideProcessors...)
+	processors = append(processors, layoutProcessor{})
+	return &SlidePipeline{
+		Parsers:    slideParsers,
+		Processors: processors,
+	}
+}
+
+// SlideSource is the source of a single slide in a file containing more than
+// one slide
+type SlideSource struct {
+	// Body is the source of the slide including its front matter
+	Body []byte
+	// Notes are speaker notes in Markdown, added to the notes of the front
+	// matter
+	Notes []byte
+	// Line is the line of the file the body starts at
+	Line int
+	// NoTemplate skips executing the body as template, for formats using
+	// [[ ]] themselves
+	NoTemplate bool
+}
+
+// SlideSplitter is implemented by the SlideParser of formats with more than
+// one slide per file. Every element of the result is a horizontal slide,
+// which is a vertical stack if it consists of more than one source.
+type SlideSplitter interface {
+	SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error)
+}
+
+// Run parses the slide file at slidePath
+func (p *SlidePipeline) Run(pres *Presentation, slidePath string) (*Slide, error) {
+	buf, err := ioutil.ReadFile(slidePath)
+	if err != nil {
+		return nil, err
+	}
+	return p.runSource(pres, slidePath, &SlideSource{Body: buf, Line: 1})
+}
+
+// RunAll parses all slides in the file at slidePath. Files split into more
+// than one slide by the SlideSplitter of their format get section IDs
+// numbered after the file, e.g. "talk-1", "talk-2", and "talk-2-1" for
+// the first slide of a vertical stack. The tags and draft of the front
+// matter at the start of the file apply to all of its slides.
+func (p *SlidePipeline) RunAll(pres *Presentation, slidePath string) ([]*Slide, error) {
+	buf, err := ioutil.ReadFile(slidePath)
+	if err != nil {
+		return nil, err
+	}
+	splitter, ok := p.parser(slidePath).(SlideSplitter)
+	if !ok {
+		s, err := p.runSource(pres, slidePath, &SlideSource{Body: buf, Line: 1})
+		if err != nil {
+			return nil, err
+		}
+		return []*Slide{s}, nil
+	}
+
+	stacks, err := splitter.SplitSlides(pres, slidePath, buf)
+	if err != nil {
+		return nil, err
+	}
+	if len(stacks) == 1 && len(stacks[0]) == 1 {
+		s, err := p.runSource(pres, slidePath, stacks[0][0])
+		if err != nil {
+			return nil, err
+		}
+		return []*Slide{s}, nil
+	}
+
+	id := generateSectionID(slidePath)
+	slides := make([]*Slide, 0, len(stacks))
+	var file *Slide
+	for i, stack := range stacks {
+		stackID := fmt.Sprintf("%s-%d", id, i+1)
+		stackSlides := make([]*Slide, 0, len(stack))
+		for j, src := range stack {
+			s, err := p.runSource(pres, slidePath, src)
+			if err != nil {
+				return nil, err
+			}
+			if file == nil {
+				file = s
+			} else {
+				s.Draft = s.Draft || file.Draft
+				for _, tag := range file.Tags {
+					if !s.hasTag(tag) {
+						s.Tags = append(s.Tags, tag)
+					}
+				}
+			}
+			s.SectionID = fmt.Sprintf("%s-%d", stackID, j+1)
+			stackSlides = append(stackSlides, s)
+		}
+		if len(stackSlides) == 1 {
+			stackSlides[0].SectionID = stackID
+			slides = append(slides, stackSlides[0])
+			continue
+		}
+		slides = append(slides, &Slide{
+			SourceFile: slidePath,
+			SubSlides:  stackSlides,
+			SectionID:  stackID,
+		})
+	}
+	return slides, nil
+}
+
+func (p *SlidePipeline) runSource(pres *Presentation, slidePath string, src *SlideSource) (*Slide, error) {
+	s, body, err := p.FrontMatter(pres, slidePath, src.Body)
+	if errs, ok := err.(ConfigErrors); ok && src.Line > 1 {
+		for _, e := range errs {
+			if e.Line > 0 {
+				e.Line += src.Line - 1
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(src.Notes)) > 0 {
+		s.Notes += renderNotes(src.Notes)
+	}
+
+	ctx := &SlideContext{
+		*s,
+		*pres,
+	}
+
+	if !src.NoTemplate {
+		body, err = p.ExpandTemplate(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := p.Parse(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Content, err = p.PostProcess(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// FrontMatter returns the slide described by the front matter of input and
+// the body following it. The front matter is either YAML between ---, TOML
+// between +++ or a JSON object.
+func (p *SlidePipeline) FrontMatter(pres *Presentation, slidePath string, input []byte) (*Slide, []byte, error) {
+	format := frontMatterFormat(input, pres)
+	frontMatter, body := parseFrontMatter(input)
+	s := p.Defaults.inherit()
+
+	if len(bytes.TrimSpace(frontMatter)) > 0 {
+		if err := decodeFrontMatter(slidePath, format, frontMatter, s); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s.SourceFile = slidePath
+	s.SectionID = generateSectionID(slidePath)
+	if err := s.validate(frontMatter); err != nil {
+		return nil, nil, err
+	}
+
+	if s.HasNotes() {
+		s.Notes = renderNotes([]byte(s.Notes))
+	}
+	return s, body, nil
+}
+
+// renderNotes renders speaker notes, which are written in Markdown
+func renderNotes(notes []byte) template.HTML {
+	return template.HTML(blackfriday.Run(notes, blackfriday.WithExtensions(
+		mardownExtensions,
+	)))
+}
+
+// ExpandTemplate executes body as template with the slide context as data.
+// See DefaultFuncMap for the available functions.
+func (p *SlidePipeline) ExpandTemplate(ctx *SlideContext, body []byte) ([]byte, error) {
+	tmpl := DefaultRenderer()
+	tmpl.Funcs(ctx.FuncMap())
+
+	tmpl, err := tmpl.New("body").Parse(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse template of slide %s: %s", ctx.SourceFile, err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, ctx); err != nil {
+		return nil, fmt.Errorf("Failed to execute template of slide %s: %s", ctx.SourceFile, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Parse converts body to HTML with the parser for the file type of the slide
+func (p *SlidePipeline) Parse(ctx *SlideContext, body []byte) (template.HTML, error) {
+	parser := p.parser(ctx.SourceFile)
+	if parser == nil {
+		return "", fmt.Errorf("No matching slide parser for file type: %s",
+			strings.TrimPrefix(filepath.Ext(ctx.SourceFile), "."))
+	}
+	return parser.ParseSlide(ctx, body)
+}
+
+// parser returns the parser for the file type of slidePath, or nil
+func (p *SlidePipeline) parser(slidePath string) SlideParser {
+	return p.Parsers[strings.TrimPrefix(filepath.Ext(slidePath), ".")]
+}
+
+// PostProcess runs all processors of the pipeline on content
+func (p *SlidePipeline) PostProcess(ctx *SlideContext, content template.HTML) (template.HTML, error) {
+	var err error
+	for _, processor := range p.Processors {
+		ctx.Content = content
+		content, err = processor.ProcessSlide(ctx, content)
+		if err != nil {
+			return "", err
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,75 @@
+package showandtell
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upperProcessor struct{}
+
+func (upperProcessor) ProcessSlide(ctx *SlideContext, content template.HTML) (template.HTML, error) {
+	return template.HTML(strings.ToUpper(string(content))), nil
+}
+
+func TestSlidePipelineTemplating(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-pipeline")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+
+	mdPath := filepath.Join(slideDir, "01_title.md")
+	htmlPath := filepath.Join(slideDir, "02_title.html")
+	require.NoError(t, ioutil.WriteFile(mdPath, []byte("+++\nnotes: some notes\n+++\n# [[ .Presentation.Name ]]"), 0644))
+	require.NoError(t, ioutil.WriteFile(htmlPath, []byte("<h1>[[ .Name ]]</h1>"), 0644))
+
+	pres := &Presentation{Name: "Pipeline"}
+	pipeline := DefaultSlidePipeline()
+
+	s, err := pipeline.Run(pres, mdPath)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Pipeline</h1>\n", string(s.Content))
+	assert.Equal(t, "<p>some notes</p>\n", string(s.Notes))
+	assert.Equal(t, "01_title", s.SectionID)
+
+	s, err = pipeline.Run(pres, htmlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>Pipeline</h1>", string(s.Content))
+
+	pipeline.Processors = append(pipeline.Processors, upperProcessor{})
+	s, err = pipeline.Run(pres, htmlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "<H1>PIPELINE</H1>", string(s.Content))
+}
+
+func TestSlidePipelineStages(t *testing.T) {
+	pipeline := DefaultSlidePipeline()
+
+	s, body, err := pipeline.FrontMatter(&Presentation{}, "slides/Some Slide.md", []byte("+++\ntransition: zoom\n+++\nbody"))
+	require.NoError(t, err)
+	require.NotNil(t, s.Transition)
+	assert.Equal(t, "zoom", *s.Transition)
+	assert.Equal(t, "some_slide", s.SectionID)
+	assert.Equal(t, "\nbody", string(body))
+
+	ctx := &SlideContext{*s, Presentation{Name: "stages"}}
+	expanded, err := pipeline.ExpandTemplate(ctx, []byte("[[ .Name ]] [[ .Transition ]]"))
+	require.NoError(t, err)
+	assert.Equal(t, "stages zoom", string(expanded))
+
+	_, err = pipeline.ExpandTemplate(ctx, []byte("[[ .DoesNotExist ]]"))
+	assert.Error(t, err)
+
+	content, err := pipeline.Parse(ctx, []byte("*stages*"))
+	require.NoError(t, err)
+	assert.Equal(t, "<p><em>stages</em></p>\n", string(content))
+
+	ctx.SourceFile = "slides/unknown.txt"
+	_, err = pipeline.Parse(ctx, []byte("foo"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,878 @@
+package showandtell
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	// Decoders for the image formats which can be embedded
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Slide geometry in EMU (English Metric Units, 914400 per inch) for 16:9
+const (
+	pptxSlideWidth  = 12192000
+	pptxSlideHeight = 6858000
+	pptxMargin      = 457200
+	pptxTitleTop    = 304800
+	pptxTitleHeight = 1143000
+	pptxBodyTop     = 1600200
+	pptxBodyHeight  = pptxSlideHeight - pptxBodyTop - pptxMargin
+	pptxContentW    = pptxSlideWidth - 2*pptxMargin
+	pptxGap         = 228600
+	// Pixels are assumed to be 96 DPI
+	pptxEMUPerPixel = 9525
+)
+
+const (
+	pptxNamespaces = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	pptxRelBase    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+	pptxTypeBase   = "application/vnd.openxmlformats-officedocument."
+	pptxTableStyle = "{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}"
+	xmlHeader      = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+// pptxRun is a piece of text with the same formatting
+type pptxRun struct {
+	text      string
+	bold      bool
+	italic    bool
+	code      bool
+	lineBreak bool
+	link      string
+}
+
+// pptxParagraph is a paragraph of a text frame. Bullet is either empty,
+// "bullet" or "number".
+type pptxParagraph struct {
+	runs   []pptxRun
+	level  int
+	bullet string
+	code   bool
+}
+
+type pptxImage struct {
+	data          []byte
+	ext           string
+	alt           string
+	width, height int
+}
+
+type pptxTable struct {
+	rows [][][]pptxRun
+}
+
+// pptxSlide is the editable structure extracted from the HTML of a slide
+type pptxSlide struct {
+	title []pptxRun
+	body  []*pptxParagraph
+	// media contains *pptxImage and *pptxTable in document order
+	media []interface{}
+	notes []*pptxParagraph
+}
+
+// ExportPPTX writes the presentation as PowerPoint file to w. Headings become
+// slide titles, text and lists become text frames, images and tables are
+// embedded and the notes of a slide become its speaker notes. The layout is
+// simplified, the goal is an editable document rather than a faithful copy.
+func ExportPPTX(w io.Writer, pres *Presentation, slideFolder string) error {
+	slides, err := ParseSlides(pres, slideFolder)
+	if err != nil {
+		return err
+	}
+	pres.Slides = slides
+
+	inliner := &assetInliner{slideFolder: slideFolder}
+	var models []*pptxSlide
+	for _, s := range flattenSlides(slides) {
+		model, err := newPPTXSlide(s, inliner)
+		if err != nil {
+			return err
+		}
+		models = append(models, model)
+	}
+	return writePPTX(w, pres, models)
+}
+
+// flattenSlides returns all slides with content in presentation order
+func flattenSlides(slides []*Slide) []*Slide {
+	var flat []*Slide
+	for _, s := range slides {
+		if len(s.SubSlides) > 0 {
+			flat = append(flat, flattenSlides(s.SubSlides)...)
+		} else {
+			flat = append(flat, s)
+		}
+	}
+	return flat
+}
+
+func newPPTXSlide(s *Slide, inliner *assetInliner) (*pptxSlide, error) {
+	model := &pptxSlide{}
+	c := &pptxConverter{slide: model, inliner: inliner, sourceFile: s.SourceFile}
+	if err := c.convert(string(s.Content)); err != nil {
+		return nil, err
+	}
+	model.body = c.paragraphs
+
+	if s.HasNotes() {
+		notes := &pptxConverter{slide: &pptxSlide{}, inliner: inliner, sourceFile: s.SourceFile, noTitle: true}
+		if err := notes.convert(string(s.Notes)); err != nil {
+			return nil, err
+		}
+		model.notes = notes.paragraphs
+	}
+	return model, nil
+}
+
+// pptxConverter walks the HTML of a slide and collects the paragraphs, title
+// and media.
+type pptxConverter struct {
+	slide      *pptxSlide
+	inliner    *assetInliner
+	sourceFile string
+	noTitle    bool
+
+	paragraphs []*pptxParagraph
+	current    *pptxParagraph
+	listStack  []string
+	err        error
+}
+
+func (c *pptxConverter) convert(content string) error {
+	nodes, err := parseHTMLFragment(content)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		c.walk(n, pptxRun{})
+	}
+	c.flush()
+	return c.err
+}
+
+// flush finishes the current paragraph
+func (c *pptxConverter) flush() {
+	if c.current == nil {
+		return
+	}
+	p := c.current
+	c.current = nil
+	if !p.code {
+		trimRuns(p)
+	}
+	if len(p.runs) > 0 || p.bullet != "" {
+		c.paragraphs = append(c.paragraphs, p)
+	}
+}
+
+func trimRuns(p *pptxParagraph) {
+	for len(p.runs) > 0 {
+		p.runs[0].text = strings.TrimLeft(p.runs[0].text, " ")
+		if p.runs[0].text != "" || p.runs[0].lineBreak {
+			break
+		}
+		p.runs = p.runs[1:]
+	}
+	for len(p.runs) > 0 {
+		last := &p.runs[len(p.runs)-1]
+		last.text = strings.TrimRight(last.text, " ")
+		if last.text != "" || last.lineBreak {
+			break
+		}
+		p.runs = p.runs[:len(p.runs)-1]
+	}
+}
+
+func (c *pptxConverter) paragraph() *pptxParagraph {
+	if c.current == nil {
+		c.current = &pptxParagraph{}
+	}
+	return c.current
+}
+
+func (c *pptxConverter) addText(text string, style pptxRun) {
+	text = strings.Join(strings.Fields(text), " ")
+	if strings.TrimSpace(text) == "" && c.current == nil {
+		return
+	}
+	if text == "" {
+		text = " "
+	}
+	style.text = text
+	p := c.paragraph()
+	p.runs = append(p.runs, style)
+}
+
+func (c *pptxConverter) walkChildren(n *html.Node, style pptxRun) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child, style)
+	}
+}
+
+// inlineRuns collects the formatted text of n
+func (c *pptxConverter) inlineRuns(n *html.Node, style pptxRun) []pptxRun {
+	sub := &pptxConverter{slide: &pptxSlide{}, inliner: c.inliner, sourceFile: c.sourceFile, noTitle: true}
+	sub.walkChildren(n, style)
+	sub.flush()
+	var runs []pptxRun
+	for i, p := range sub.paragraphs {
+		if i > 0 {
+			runs = append(runs, pptxRun{lineBreak: true})
+		}
+		runs = append(runs, p.runs...)
+	}
+	return runs
+}
+
+func (c *pptxConverter) walk(n *html.Node, style pptxRun) {
+	switch n.Type {
+	case html.TextNode:
+		c.addText(n.Data, style)
+		return
+	case html.ElementNode:
+	default:
+		c.walkChildren(n, style)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Aside, atom.Svg:
+		// Notes are handled separately, scripts and vector graphics can't be
+		// represented
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.flush()
+		if !c.noTitle && c.slide.title == nil {
+			c.slide.title = c.inlineRuns(n, style)
+			return
+		}
+		style.bold = true
+		c.walkChildren(n, style)
+		c.flush()
+	case atom.Ul, atom.Ol:
+		c.flush()
+		kind := "bullet"
+		if n.DataAtom == atom.Ol {
+			kind = "number"
+		}
+		c.listStack = append(c.listStack, kind)
+		c.walkChildren(n, style)
+		c.listStack = c.listStack[:len(c.listStack)-1]
+	case atom.Li:
+		c.flush()
+		p := c.paragraph()
+		p.level = len(c.listStack) - 1
+		if p.level >= 0 {
+			p.bullet = c.listStack[p.level]
+		} else {
+			p.level = 0
+		}
+		c.walkChildren(n, style)
+		c.flush()
+	case atom.Pre:
+		c.flush()
+		for _, line := range strings.Split(strings.TrimSuffix(codeText(n), "\n"), "\n") {
+			c.paragraphs = append(c.paragraphs, &pptxParagraph{
+				runs: []pptxRun{{text: line, code: true}},
+				code: true,
+			})
+		}
+	case atom.P, atom.Div, atom.Blockquote, atom.Section, atom.Header, atom.Footer, atom.Figure, atom.Dl, atom.Dt, atom.Dd:
+		c.flush()
+		c.walkChildren(n, style)
+		c.flush()
+	case atom.Br:
+		p := c.paragraph()
+		p.runs = append(p.runs, pptxRun{lineBreak: true})
+	case atom.Strong, atom.B:
+		style.bold = true
+		c.walkChildren(n, style)
+	case atom.Em, atom.I:
+		style.italic = true
+		c.walkChildren(n, style)
+	case atom.Code, atom.Kbd, atom.Samp:
+		style.code = true
+		c.walkChildren(n, style)
+	case atom.A:
+		if href := attr(n, "href"); href != "" && !strings.HasPrefix(href, "#") {
+			style.link = href
+		}
+		c.walkChildren(n, style)
+	case atom.Img:
+		c.addImage(n)
+	case atom.Math:
+		c.addText(mathText(n), style)
+	case atom.Table:
+		c.slide.media = append(c.slide.media, c.table(n, style))
+	default:
+		c.walkChildren(n, style)
+	}
+}
+
+func (c *pptxConverter) addImage(n *html.Node) {
+	src := attr(n, "src")
+	var data []byte
+	var err error
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(src), "."))
+	if strings.HasPrefix(src, "data:") {
+		data, ext, err = decodeDataURI(src)
+	} else if isRemoteRef(src) {
+		c.addText("[image: "+src+"]", pptxRun{italic: true})
+		return
+	} else {
+		data, err = c.inliner.findImage(src)
+	}
+	if err != nil {
+		if c.err == nil {
+			c.err = fmt.Errorf("Slide %s: %s", c.sourceFile, err)
+		}
+		return
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Unsupported formats like SVG are replaced by their description
+		alt := attr(n, "alt")
+		if alt == "" {
+			alt = src
+		}
+		c.addText("[image: "+alt+"]", pptxRun{italic: true})
+		return
+	}
+	if format == "jpeg" {
+		ext = "jpeg"
+	} else {
+		ext = format
+	}
+	c.slide.media = append(c.slide.media, &pptxImage{
+		data:   data,
+		ext:    ext,
+		alt:    attr(n, "alt"),
+		width:  cfg.Width,
+		height: cfg.Height,
+	})
+}
+
+func decodeDataURI(uri string) ([]byte, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(uri, "data:"), ",", 2)
+	if len(parts) != 2 || !strings.HasSuffix(parts[0], ";base64") {
+		return nil, "", fmt.Errorf("Unsupported data URI")
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	mimeType := strings.TrimSuffix(parts[0], ";base64")
+	return data, path.Base(mimeType), err
+}
+
+func (c *pptxConverter) table(n *html.Node, style pptxRun) *pptxTable {
+	t := &pptxTable{}
+	var collectRows func(n *html.Node)
+	collectRows = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var row [][]pptxRun
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						cellStyle := style
+						cellStyle.bold = cell.DataAtom == atom.Th
+						row = append(row, c.inlineRuns(cell, cellStyle))
+					}
+				}
+				t.rows = append(t.rows, row)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collectRows(child)
+			}
+		}
+	}
+	collectRows(n)
+	return t
+}
+
+// pptxPackage collects the parts of the Office Open XML package
+type pptxPackage struct {
+	zip          *zip.Writer
+	contentTypes []string
+	imageTypes   map[string]bool
+	err          error
+}
+
+func (p *pptxPackage) add(name, contentType, content string) {
+	if p.err != nil {
+		return
+	}
+	if contentType != "" {
+		p.contentTypes = append(p.contentTypes,
+			fmt.Sprintf(`<Override PartName="/%s" ContentType="%s"/>`, name, contentType))
+	}
+	f, err := p.zip.Create(name)
+	if err != nil {
+		p.err = err
+		return
+	}
+	_, p.err = io.WriteString(f, content)
+}
+
+func (p *pptxPackage) addBytes(name string, content []byte) {
+	if p.err != nil {
+		return
+	}
+	f, err := p.zip.Create(name)
+	if err != nil {
+		p.err = err
+		return
+	}
+	_, p.err = f.Write(content)
+}
+
+// pptxRels builds a relationship part
+type pptxRels struct {
+	rels []string
+}
+
+func (r *pptxRels) add(relType, target string) string {
+	id := fmt.Sprintf("rId%d", len(r.rels)+1)
+	r.rels = append(r.rels, fmt.Sprintf(`<Relationship Id="%s" Type="%s%s" Target="%s"/>`,
+		id, pptxRelBase, relType, escapeXML(target)))
+	return id
+}
+
+func (r *pptxRels) addExternal(relType, target string) string {
+	id := fmt.Sprintf("rId%d", len(r.rels)+1)
+	r.rels = append(r.rels, fmt.Sprintf(`<Relationship Id="%s" Type="%s%s" Target="%s" TargetMode="External"/>`,
+		id, pptxRelBase, relType, escapeXML(target)))
+	return id
+}
+
+func (r *pptxRels) String() string {
+	return xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		strings.Join(r.rels, "") + `</Relationships>`
+}
+
+func escapeXML(s string) string {
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
+
+func writePPTX(w io.Writer, pres *Presentation, slides []*pptxSlide) error {
+	pkg := &pptxPackage{zip: zip.NewWriter(w), imageTypes: map[string]bool{}}
+
+	rootRels := &pptxRels{}
+	rootRels.add("officeDocument", "ppt/presentation.xml")
+	rootRels.rels = append(rootRels.rels, `<Relationship Id="rId2" `+
+		`Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" `+
+		`Target="docProps/core.xml"/>`)
+	pkg.add("_rels/.rels", "", rootRels.String())
+	pkg.add("docProps/core.xml", "application/vnd.openxmlformats-package.core-properties+xml", xmlHeader+
+		`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" `+
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>`+escapeXML(pres.Name)+`</dc:title>`+
+		`<dc:description>`+escapeXML(pres.Description)+`</dc:description></cp:coreProperties>`)
+
+	presRels := &pptxRels{}
+	masterRel := presRels.add("slideMaster", "slideMasters/slideMaster1.xml")
+	presRels.add("theme", "theme/theme1.xml")
+	notesMasterRel := presRels.add("notesMaster", "notesMasters/notesMaster1.xml")
+	presRels.add("presProps", "presProps.xml")
+	presRels.add("viewProps", "viewProps.xml")
+	presRels.add("tableStyles", "tableStyles.xml")
+
+	slideIDs := &strings.Builder{}
+	imageCount := 0
+	for i, s := range slides {
+		num := i + 1
+		rel := presRels.add("slide", fmt.Sprintf("slides/slide%d.xml", num))
+		fmt.Fprintf(slideIDs, `<p:sldId id="%d" r:id="%s"/>`, 256+i, rel)
+
+		slideRels := &pptxRels{}
+		slideRels.add("slideLayout", "../slideLayouts/slideLayout1.xml")
+		slideRels.add("notesSlide", fmt.Sprintf("../notesSlides/notesSlide%d.xml", num))
+		images := map[*pptxImage]string{}
+		for _, m := range s.media {
+			if img, ok := m.(*pptxImage); ok {
+				imageCount++
+				name := fmt.Sprintf("image%d.%s", imageCount, img.ext)
+				pkg.imageTypes[img.ext] = true
+				pkg.addBytes("ppt/media/"+name, img.data)
+				images[img] = slideRels.add("image", "../media/"+name)
+			}
+		}
+		slideXML := pptxSlideXML(s, images, slideRels)
+		pkg.add(fmt.Sprintf("ppt/slides/slide%d.xml", num), pptxTypeBase+"presentationml.slide+xml", slideXML)
+		pkg.add(fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", num), "", slideRels.String())
+
+		notesRels := &pptxRels{}
+		notesRels.add("notesMaster", "../notesMasters/notesMaster1.xml")
+		notesRels.add("slide", fmt.Sprintf("../slides/slide%d.xml", num))
+		pkg.add(fmt.Sprintf("ppt/notesSlides/notesSlide%d.xml", num), pptxTypeBase+"presentationml.notesSlide+xml",
+			pptxNotesXML(s.notes, notesRels))
+		pkg.add(fmt.Sprintf("ppt/notesSlides/_rels/notesSlide%d.xml.rels", num), "", notesRels.String())
+	}
+
+	sldIDList := ""
+	if slideIDs.Len() > 0 {
+		sldIDList = "<p:sldIdLst>" + slideIDs.String() + "</p:sldIdLst>"
+	}
+	pkg.add("ppt/presentation.xml", pptxTypeBase+"presentationml.presentation.main+xml", xmlHeader+
+		`<p:presentation `+pptxNamespaces+` saveSubsetFonts="1">`+
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="`+masterRel+`"/></p:sldMasterIdLst>`+
+		`<p:notesMasterIdLst><p:notesMasterId r:id="`+notesMasterRel+`"/></p:notesMasterIdLst>`+
+		sldIDList+
+		fmt.Sprintf(`<p:sldSz cx="%d" cy="%d"/><p:notesSz cx="6858000" cy="9144000"/>`, pptxSlideWidth, pptxSlideHeight)+
+		`</p:presentation>`)
+	pkg.add("ppt/_rels/presentation.xml.rels", "", presRels.String())
+	pkg.add("ppt/presProps.xml", pptxTypeBase+"presentationml.presProps+xml",
+		xmlHeader+`<p:presentationPr `+pptxNamespaces+`/>`)
+	pkg.add("ppt/viewProps.xml", pptxTypeBase+"presentationml.viewProps+xml",
+		xmlHeader+`<p:viewPr `+pptxNamespaces+`/>`)
+	pkg.add("ppt/tableStyles.xml", pptxTypeBase+"presentationml.tableStyles+xml",
+		xmlHeader+`<a:tblStyleLst xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" def="`+pptxTableStyle+`"/>`)
+
+	masterRels := &pptxRels{}
+	masterRels.add("slideLayout", "../slideLayouts/slideLayout1.xml")
+	masterRels.add("theme", "../theme/theme1.xml")
+	pkg.add("ppt/slideMasters/slideMaster1.xml", pptxTypeBase+"presentationml.slideMaster+xml", pptxSlideMaster)
+	pkg.add("ppt/slideMasters/_rels/slideMaster1.xml.rels", "", masterRels.String())
+
+	layoutRels := &pptxRels{}
+	layoutRels.add("slideMaster", "../slideMasters/slideMaster1.xml")
+	pkg.add("ppt/slideLayouts/slideLayout1.xml", pptxTypeBase+"presentationml.slideLayout+xml", pptxSlideLayout)
+	pkg.add("ppt/slideLayouts/_rels/slideLayout1.xml.rels", "", layoutRels.String())
+
+	notesMasterRels := &pptxRels{}
+	notesMasterRels.add("theme", "../theme/theme2.xml")
+	pkg.add("ppt/notesMasters/notesMaster1.xml", pptxTypeBase+"presentationml.notesMaster+xml", pptxNotesMaster)
+	pkg.add("ppt/notesMasters/_rels/notesMaster1.xml.rels", "", notesMasterRels.String())
+
+	pkg.add("ppt/theme/theme1.xml", pptxTypeBase+"theme+xml", pptxTheme)
+	pkg.add("ppt/theme/theme2.xml", pptxTypeBase+"theme+xml", pptxTheme)
+
+	types := &strings.Builder{}
+	types.WriteString(xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	types.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	types.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	for ext := range pkg.imageTypes {
+		fmt.Fprintf(types, `<Default Extension="%s" ContentType="image/%s"/>`, ext, ext)
+	}
+	types.WriteString(strings.Join(pkg.contentTypes, ""))
+	types.WriteString(`</Types>`)
+	pkg.add("[Content_Types].xml", "", types.String())
+
+	if pkg.err != nil {
+		return pkg.err
+	}
+	return pkg.zip.Close()
+}
+
+func pptxRunsXML(runs []pptxRun, size int, rels *pptxRels) string {
+	buf := &strings.Builder{}
+	for _, r := range runs {
+		if r.lineBreak {
+			buf.WriteString(`<a:br/>`)
+			continue
+		}
+		buf.WriteString(`<a:r><a:rPr lang="en-US"`)
+		if size > 0 {
+			fmt.Fprintf(buf, ` sz="%d"`, size)
+		}
+		if r.bold {
+			buf.WriteString(` b="1"`)
+		}
+		if r.italic {
+			buf.WriteString(` i="1"`)
+		}
+		buf.WriteString(` dirty="0">`)
+		if r.code {
+			buf.WriteString(`<a:latin typeface="Courier New"/><a:cs typeface="Courier New"/>`)
+		}
+		if r.link != "" && rels != nil {
+			fmt.Fprintf(buf, `<a:hlinkClick r:id="%s"/>`, rels.addExternal("hyperlink", r.link))
+		}
+		buf.WriteString(`</a:rPr><a:t>` + escapeXML(r.text) + `</a:t></a:r>`)
+	}
+	return buf.String()
+}
+
+func pptxParagraphsXML(paragraphs []*pptxParagraph, rels *pptxRels) string {
+	buf := &strings.Builder{}
+	for _, p := range paragraphs {
+		buf.WriteString(`<a:p>`)
+		switch p.bullet {
+		case "bullet":
+			fmt.Fprintf(buf, `<a:pPr marL="%d" lvl="%d" indent="-285750"><a:buFont typeface="Arial"/><a:buChar char="&#8226;"/></a:pPr>`,
+				342900+p.level*457200, p.level)
+		case "number":
+			fmt.Fprintf(buf, `<a:pPr marL="%d" lvl="%d" indent="-342900"><a:buFont typeface="+mj-lt"/><a:buAutoNum type="arabicPeriod"/></a:pPr>`,
+				342900+p.level*457200, p.level)
+		default:
+			buf.WriteString(`<a:pPr marL="0" indent="0"><a:buNone/></a:pPr>`)
+		}
+		size := 0
+		if p.code {
+			size = 1400
+		}
+		buf.WriteString(pptxRunsXML(p.runs, size, rels))
+		buf.WriteString(`</a:p>`)
+	}
+	if len(paragraphs) == 0 {
+		buf.WriteString(`<a:p><a:endParaRPr lang="en-US" dirty="0"/></a:p>`)
+	}
+	return buf.String()
+}
+
+func pptxXfrm(tag string, x, y, cx, cy int) string {
+	return fmt.Sprintf(`<%s><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></%s>`, tag, x, y, cx, cy, tag)
+}
+
+const pptxGroupProps = `<p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+func pptxSlideXML(s *pptxSlide, images map[*pptxImage]string, rels *pptxRels) string {
+	buf := &strings.Builder{}
+	buf.WriteString(xmlHeader + `<p:sld ` + pptxNamespaces + `><p:cSld><p:spTree>` + pptxGroupProps)
+
+	shapeID := 2
+	if s.title != nil {
+		fmt.Fprintf(buf, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Title %d"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>`+
+			`<p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:spPr>%s</p:spPr>`+
+			`<p:txBody><a:bodyPr><a:normAutofit/></a:bodyPr><a:lstStyle/><a:p>%s</a:p></p:txBody></p:sp>`,
+			shapeID, shapeID-1, pptxXfrm("a:xfrm", pptxMargin, pptxTitleTop, pptxContentW, pptxTitleHeight),
+			pptxRunsXML(s.title, 0, rels))
+		shapeID++
+	}
+
+	// Text takes the left half if there are images or tables, which are
+	// stacked on the right. Without text they use the full width.
+	textWidth, mediaX, mediaWidth := pptxContentW, pptxMargin, pptxContentW
+	if len(s.body) > 0 && len(s.media) > 0 {
+		textWidth = (pptxContentW - pptxGap) / 2
+		mediaX = pptxMargin + textWidth + pptxGap
+		mediaWidth = textWidth
+	}
+
+	if len(s.body) > 0 {
+		fmt.Fprintf(buf, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="Content %d"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>`+
+			`<p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr><p:spPr>%s</p:spPr>`+
+			`<p:txBody><a:bodyPr><a:normAutofit/></a:bodyPr><a:lstStyle/>%s</p:txBody></p:sp>`,
+			shapeID, shapeID-1, pptxXfrm("a:xfrm", pptxMargin, pptxBodyTop, textWidth, pptxBodyHeight),
+			pptxParagraphsXML(s.body, rels))
+		shapeID++
+	}
+
+	if len(s.media) > 0 {
+		slotHeight := (pptxBodyHeight - (len(s.media)-1)*pptxGap) / len(s.media)
+		for i, m := range s.media {
+			y := pptxBodyTop + i*(slotHeight+pptxGap)
+			switch m := m.(type) {
+			case *pptxImage:
+				cx, cy := fitImage(m.width, m.height, mediaWidth, slotHeight)
+				x := mediaX + (mediaWidth-cx)/2
+				fmt.Fprintf(buf, `<p:pic><p:nvPicPr><p:cNvPr id="%d" name="Picture %d" descr="%s"/>`+
+					`<p:cNvPicPr><a:picLocks noChangeAspect="1"/></p:cNvPicPr><p:nvPr/></p:nvPicPr>`+
+					`<p:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></p:blipFill>`+
+					`<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr></p:pic>`,
+					shapeID, shapeID-1, escapeXML(m.alt), images[m], pptxXfrm("a:xfrm", x, y, cx, cy))
+			case *pptxTable:
+				buf.WriteString(pptxTableXML(m, shapeID, mediaX, y, mediaWidth, slotHeight, rels))
+			}
+			shapeID++
+		}
+	}
+
+	buf.WriteString(`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`)
+	return buf.String()
+}
+
+// fitImage scales an image of the given pixel size into the box, keeping its
+// aspect ratio and never scaling it up.
+func fitImage(width, height, boxWidth, boxHeight int) (int, int) {
+	cx, cy := width*pptxEMUPerPixel, height*pptxEMUPerPixel
+	if cx == 0 || cy == 0 {
+		return boxWidth, boxHeight
+	}
+	if cx > boxWidth {
+		cy = cy * boxWidth / cx
+		cx = boxWidth
+	}
+	if cy > boxHeight {
+		cx = cx * boxHeight / cy
+		cy = boxHeight
+	}
+	return cx, cy
+}
+
+func pptxTableXML(t *pptxTable, shapeID, x, y, width, height int, rels *pptxRels) string {
+	columns := 0
+	for _, row := range t.rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+	colWidth := width / columns
+	rowHeight := 370840
+	if len(t.rows)*rowHeight > height {
+		rowHeight = height / len(t.rows)
+	}
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, `<p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="%d" name="Table %d"/>`+
+		`<p:cNvGraphicFramePr><a:graphicFrameLocks noGrp="1"/></p:cNvGraphicFramePr><p:nvPr/></p:nvGraphicFramePr>%s`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/table"><a:tbl>`+
+		`<a:tblPr firstRow="1" bandRow="1"><a:tableStyleId>%s</a:tableStyleId></a:tblPr><a:tblGrid>`,
+		shapeID, shapeID-1, pptxXfrm("p:xfrm", x, y, colWidth*columns, rowHeight*len(t.rows)), pptxTableStyle)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(buf, `<a:gridCol w="%d"/>`, colWidth)
+	}
+	buf.WriteString(`</a:tblGrid>`)
+	for _, row := range t.rows {
+		fmt.Fprintf(buf, `<a:tr h="%d">`, rowHeight)
+		for i := 0; i < columns; i++ {
+			var runs []pptxRun
+			if i < len(row) {
+				runs = row[i]
+			}
+			buf.WriteString(`<a:tc><a:txBody><a:bodyPr/><a:lstStyle/><a:p>` + pptxRunsXML(runs, 1400, rels) +
+				`</a:p></a:txBody><a:tcPr/></a:tc>`)
+		}
+		buf.WriteString(`</a:tr>`)
+	}
+	buf.WriteString(`</a:tbl></a:graphicData></a:graphic></p:graphicFrame>`)
+	return buf.String()
+}
+
+func pptxNotesXML(notes []*pptxParagraph, rels *pptxRels) string {
+	return xmlHeader + `<p:notes ` + pptxNamespaces + `><p:cSld><p:spTree>` + pptxGroupProps +
+		`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr>` +
+		`<p:nvPr><p:ph type="sldImg"/></p:nvPr></p:nvSpPr><p:spPr/></p:sp>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+		`<p:nvPr><p:ph type="body" idx="3"/></p:nvPr></p:nvSpPr><p:spPr/>` +
+		`<p:txBody><a:bodyPr/><a:lstStyle/>` + pptxParagraphsXML(notes, rels) + `</p:txBody></p:sp>` +
+		`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:notes>`
+}
+
+const pptxColorMap = `bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" ` +
+	`accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"`
+
+func pptxLevelStyle(tag string, size int, bullet bool) string {
+	bu := `<a:buNone/>`
+	if bullet {
+		bu = `<a:buFont typeface="Arial"/><a:buChar char="&#8226;"/>`
+	}
+	return fmt.Sprintf(`<%s algn="l">%s<a:defRPr sz="%d"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>`+
+		`<a:latin typeface="+mn-lt"/></a:defRPr></%s>`, tag, bu, size, tag)
+}
+
+var pptxSlideMaster = xmlHeader + `<p:sldMaster ` + pptxNamespaces + `><p:cSld>` +
+	`<p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg><p:spTree>` + pptxGroupProps +
+	`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:spPr>` +
+	pptxXfrm("a:xfrm", pptxMargin, pptxTitleTop, pptxContentW, pptxTitleHeight) +
+	`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+	`<p:txBody><a:bodyPr anchor="ctr"><a:normAutofit/></a:bodyPr><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Text Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr><p:spPr>` +
+	pptxXfrm("a:xfrm", pptxMargin, pptxBodyTop, pptxContentW, pptxBodyHeight) +
+	`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+	`<p:txBody><a:bodyPr><a:normAutofit/></a:bodyPr><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld><p:clrMap ` + pptxColorMap + `/>` +
+	`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst>` +
+	`<p:txStyles><p:titleStyle>` + pptxLevelStyle("a:lvl1pPr", 4000, false) + `</p:titleStyle>` +
+	`<p:bodyStyle>` + pptxLevelStyle("a:lvl1pPr", 2400, true) + pptxLevelStyle("a:lvl2pPr", 2000, true) +
+	pptxLevelStyle("a:lvl3pPr", 1800, true) + `</p:bodyStyle>` +
+	`<p:otherStyle>` + pptxLevelStyle("a:lvl1pPr", 1800, false) + `</p:otherStyle></p:txStyles></p:sldMaster>`
+
+var pptxSlideLayout = xmlHeader + `<p:sldLayout ` + pptxNamespaces + ` type="obj" preserve="1">` +
+	`<p:cSld name="Title and Content"><p:spTree>` + pptxGroupProps +
+	`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title 1"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:spPr/>` +
+	`<p:txBody><a:bodyPr/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Content Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr><p:spPr/>` +
+	`<p:txBody><a:bodyPr/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`
+
+var pptxNotesMaster = xmlHeader + `<p:notesMaster ` + pptxNamespaces + `><p:cSld>` +
+	`<p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg><p:spTree>` + pptxGroupProps +
+	`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph type="sldImg" idx="2"/></p:nvPr></p:nvSpPr><p:spPr>` + pptxXfrm("a:xfrm", 685800, 1143000, 5486400, 3086100) +
+	`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom><a:noFill/><a:ln w="12700"><a:solidFill><a:prstClr val="black"/></a:solidFill></a:ln></p:spPr></p:sp>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>` +
+	`<p:nvPr><p:ph type="body" sz="quarter" idx="3"/></p:nvPr></p:nvSpPr><p:spPr>` + pptxXfrm("a:xfrm", 685800, 4400550, 5486400, 3600450) +
+	`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+	`<p:txBody><a:bodyPr/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld><p:clrMap ` + pptxColorMap + `/>` +
+	`<p:notesStyle>` + pptxLevelStyle("a:lvl1pPr", 1200, false) + `</p:notesStyle></p:notesMaster>`
+
+var pptxTheme = xmlHeader + `<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="showandtell">` +
+	`<a:themeElements><a:clrScheme name="showandtell">` +
+	`<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1><a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2>` +
+	`<a:accent3><a:srgbClr val="A5A5A5"/></a:accent3><a:accent4><a:srgbClr val="FFC000"/></a:accent4>` +
+	`<a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink></a:clrScheme>` +
+	`<a:fontScheme name="showandtell">` +
+	`<a:majorFont><a:latin typeface="Calibri Light"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont></a:fontScheme>` +
+	`<a:fmtScheme name="showandtell"><a:fillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) +
+	`</a:fillStyleLst><a:lnStyleLst>` + strings.Repeat(`<a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>`, 3) +
+	`</a:lnStyleLst><a:effectStyleLst>` + strings.Repeat(`<a:effectStyle><a:effectLst/></a:effectStyle>`, 3) +
+	`</a:effectStyleLst><a:bgFillStyleLst>` + strings.Repeat(`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>`, 3) +
+	`</a:bgFillStyleLst></a:fmtScheme></a:themeElements><a:objectDefaults/><a:extraClrSchemeLst/></a:theme>`
+
+// mathText returns the text of rendered math without its TeX annotation
+func mathText(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Annotation {
+		return ""
+	}
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	buf := &strings.Builder{}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		buf.WriteString(mathText(child))
+	}
+	return buf.String()
+}
+
+// codeText returns the text of a code block. Only the first step of
+// highlighted code is used and line numbers are left out.
+func codeText(pre *html.Node) string {
+	for child := pre.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Code {
+			pre = child
+			break
+		}
+	}
+	var text func(n *html.Node) string
+	text = func(n *html.Node) string {
+		if n.Type == html.TextNode {
+			return n.Data
+		}
+		if strings.Contains(" "+attr(n, "class")+" ", " ln ") {
+			return ""
+		}
+		buf := &strings.Builder{}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			buf.WriteString(text(child))
+		}
+		return buf.String()
+	}
+	return text(pre)
+}
@@ -0,0 +1,119 @@
+package showandtell
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPPTX(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-pptx")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+
+	img := &bytes.Buffer{}
+	require.NoError(t, png.Encode(img, image.NewRGBA(image.Rect(0, 0, 40, 20))))
+	require.NoError(t, imagesBox.AddBytes("chart.png", img.Bytes()))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_intro.md"), []byte(`+++
+notes: Say **hello**
++++
+# Hello & welcome
+
+Some *text* with [a link](https://example.com)
+
+* one
+  * nested
+* two
+
+![chart](images/chart.png)
+`), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(slideDir, "02_chapter"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "02_chapter", "01_table.md"), []byte(`
+## Numbers
+
+| a | b |
+|---|---|
+| 1 | 2 |
+`), 0644))
+
+	pres := &Presentation{Name: "Test <pres>", RevealConfig: DefaultRevealConfig()}
+	out := &bytes.Buffer{}
+	require.NoError(t, ExportPPTX(out, pres, slideDir))
+
+	r, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		buf, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(buf)
+
+		if filepath.Ext(f.Name) == ".xml" || filepath.Ext(f.Name) == ".rels" {
+			assertWellFormed(t, f.Name, buf)
+		}
+	}
+
+	for _, name := range []string{
+		"[Content_Types].xml",
+		"ppt/presentation.xml",
+		"ppt/slides/slide1.xml",
+		"ppt/slides/slide2.xml",
+		"ppt/notesSlides/notesSlide1.xml",
+		"ppt/media/image1.png",
+	} {
+		assert.Contains(t, parts, name)
+	}
+	assert.NotContains(t, parts, "ppt/slides/slide3.xml")
+
+	slide1 := parts["ppt/slides/slide1.xml"]
+	assert.Contains(t, slide1, `<p:ph type="title"/>`)
+	assert.Contains(t, slide1, `<a:t>Hello &amp; welcome</a:t>`)
+	assert.Contains(t, slide1, `i="1"`)
+	assert.Contains(t, slide1, `lvl="1"`)
+	assert.Contains(t, slide1, `<p:pic>`)
+	assert.Contains(t, parts["ppt/slides/_rels/slide1.xml.rels"], `Target="https://example.com" TargetMode="External"`)
+	assert.Contains(t, parts["ppt/notesSlides/notesSlide1.xml"], `<a:t>hello</a:t>`)
+	assert.Contains(t, parts["[Content_Types].xml"], `<Default Extension="png" ContentType="image/png"/>`)
+
+	slide2 := parts["ppt/slides/slide2.xml"]
+	assert.Contains(t, slide2, `<a:tbl>`)
+	assert.Contains(t, slide2, `<a:t>Numbers</a:t>`)
+}
+
+func TestExportPPTXMissingImage(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-pptx")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_image.md"), []byte(`![missing](missing.png)`), 0644))
+
+	pres := &Presentation{Name: "foo", RevealConfig: DefaultRevealConfig()}
+	err = ExportPPTX(ioutil.Discard, pres, slideDir)
+	assert.Error(t, err)
+}
+
+func assertWellFormed(t *testing.T, name string, buf []byte) {
+	t.Helper()
+	decoder := xml.NewDecoder(bytes.NewReader(buf))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		if !assert.NoError(t, err, name) {
+			return
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package showandtell
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterSlideFormat("slide", &PresentSlideParser{})
+}
+
+// PresentSlideParser reads talks written for golang.org/x/tools/present:
+//
+//	Title of the talk
+//	Subtitle
+//	15:04 2 Jan 2006
+//	Tags: go, talk
+//
+//	Author Name
+//	Job, Company
+//	author@example.com
+//	https://example.com/
+//	@twitter
+//
+//	* Title of a slide
+//
+//	Text with _italic_, *bold*, `code` and [[https://golang.org][links]].
+//
+//	- bullets
+//
+//	  indented preformatted text
+//
+//	.code hello.go /^func main/,/^}/
+//	.image gopher.png 200 _
+//
+//	: presenter notes
+//
+// The title block is the first slide and every * section another one. Files
+// of .code, .play and .html are relative to the slide file, images relative
+// to the slide folder like those of other slides. As every file in the slide
+// folder is a slide, keep code outside of it or list the slides in the
+// outline. Links use [[ ]], so the slides aren't executed as template.
+type PresentSlideParser struct{}
+
+type presentSection struct {
+	title      string
+	background string
+	body       []string
+	notes      []string
+	line       int
+}
+
+func isPresentNote(line string) bool {
+	return line == ":" || strings.HasPrefix(line, ": ")
+}
+
+// SplitSlides splits a talk into the title slide and one slide per section
+func (p *PresentSlideParser) SplitSlides(pres *Presentation, slidePath string, input []byte) ([][]*SlideSource, error) {
+	lines := strings.Split(strings.Replace(string(input), "\r\n", "\n", -1), "\n")
+	sections := []*presentSection{{title: strings.TrimSpace(lines[0]), line: 1}}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "* ") {
+			sections = append(sections, &presentSection{title: strings.TrimSpace(line[2:]), line: i + 1})
+		}
+		section := sections[len(sections)-1]
+		switch {
+		case isPresentNote(line):
+			section.notes = append(section.notes, strings.TrimSpace(line[1:]))
+			continue
+		case strings.HasPrefix(line, ".background "):
+			section.background = strings.TrimSpace(line[len(".background "):])
+		}
+		section.body = append(section.body, line)
+	}
+	if strings.HasPrefix(lines[0], "* ") {
+		// No title block
+		sections = sections[1:]
+	}
+
+	stacks := make([][]*SlideSource, 0, len(sections))
+	for _, section := range sections {
+		frontMatter := map[string]interface{}{"title": section.title}
+		if section.background != "" {
+			frontMatter["background"] = map[string]string{"image": section.background}
+		}
+		buf, err := json.Marshal(frontMatter)
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, []*SlideSource{{
+			Body:       []byte(string(buf) + "\n" + strings.Join(section.body, "\n")),
+			Notes:      []byte(strings.Join(section.notes, "\n")),
+			Line:       section.line,
+			NoTemplate: true,
+		}})
+	}
+	return stacks, nil
+}
+
+func (p *PresentSlideParser) ParseSlide(ctx *SlideContext, input []byte) (template.HTML, error) {
+	text := strings.Trim(strings.Replace(string(input), "\r\n", "\n", -1), "\n")
+	if text == "" {
+		return "", nil
+	}
+	lines := strings.Split(text, "\n")
+	if presentHeading(lines[0]) == 0 {
+		return presentTitle(lines), nil
+	}
+
+	buf := &strings.Builder{}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "" || isPresentNote(line) || strings.HasPrefix(line, "//"):
+			i++
+		case presentHeading(line) > 0:
+			level := presentHeading(line)
+			fmt.Fprintf(buf, "<h%d>%s</h%d>\n", level+1, presentStyle(strings.TrimSpace(line[level:])), level+1)
+			i++
+		case strings.HasPrefix(line, "- "):
+			buf.WriteString("<ul>\n")
+			for ; i < len(lines) && strings.HasPrefix(lines[i], "- "); i++ {
+				fmt.Fprintf(buf, "<li>%s</li>\n", presentStyle(strings.TrimSpace(lines[i][2:])))
+			}
+			buf.WriteString("</ul>\n")
+		case line[0] == ' ' || line[0] == '\t':
+			var pre []string
+			for ; i < len(lines) && (strings.TrimSpace(lines[i]) == "" || lines[i][0] == ' ' || lines[i][0] == '\t'); i++ {
+				pre = append(pre, lines[i])
+			}
+			for len(pre) > 0 && strings.TrimSpace(pre[len(pre)-1]) == "" {
+				pre = pre[:len(pre)-1]
+			}
+			fmt.Fprintf(buf, "<pre><code>%s\n</code></pre>\n", template.HTMLEscapeString(dedent(pre)))
+		case line[0] == '.':
+			out, err := presentCommand(ctx, line)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(out)
+			i++
+		default:
+			var paragraph []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isPresentNote(lines[i]) &&
+				!strings.HasPrefix(lines[i], ".") && !strings.HasPrefix(lines[i], "- "); i++ {
+				paragraph = append(paragraph, presentStyle(strings.TrimSpace(lines[i])))
+			}
+			fmt.Fprintf(buf, "<p>%s</p>\n", strings.Join(paragraph, "\n"))
+		}
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// presentHeading returns the level of a heading like "** Subsection", or 0
+func presentHeading(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "*"))
+	if level == 0 || !strings.HasPrefix(line[level:], " ") {
+		return 0
+	}
+	return level
+}
+
+// dedent removes the indentation common to all non-empty lines
+func dedent(lines []string) string {
+	indent := ""
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if indent == "" || len(lineIndent) < len(indent) {
+			indent = lineIndent
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.Join(out, "\n")
+}
+
+// presentTitle renders the title block with the authors
+func presentTitle(lines []string) template.HTML {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "<h1>%s</h1>\n", presentStyle(strings.TrimSpace(lines[0])))
+	i := 1
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "Tags:"), strings.HasPrefix(line, "Summary:"), strings.HasPrefix(line, "OldURL:"):
+		case isPresentTime(line):
+			fmt.Fprintf(buf, "<p class=\"date\">%s</p>\n", template.HTMLEscapeString(line))
+		default:
+			fmt.Fprintf(buf, "<h2>%s</h2>\n", presentStyle(line))
+		}
+	}
+
+	inAuthor := false
+	for ; i <= len(lines); i++ {
+		if i == len(lines) || strings.TrimSpace(lines[i]) == "" {
+			if inAuthor {
+				buf.WriteString("</div>\n")
+			}
+			inAuthor = false
+			continue
+		}
+		if !inAuthor {
+			buf.WriteString("<div class=\"author\">\n")
+			inAuthor = true
+		}
+		line := strings.TrimSpace(lines[i])
+		var out string
+		switch {
+		case strings.HasPrefix(line, "http://"), strings.HasPrefix(line, "https://"):
+			out = presentLink(line, "")
+		case strings.HasPrefix(line, "@") && !strings.Contains(line, " "):
+			out = presentLink("https://twitter.com/"+line[1:], line)
+		case strings.Contains(line, "@") && !strings.Contains(line, " "):
+			out = presentLink("mailto:"+line, line)
+		default:
+			out = presentStyle(line)
+		}
+		fmt.Fprintf(buf, "<p>%s</p>\n", out)
+	}
+	return template.HTML(buf.String())
+}
+
+func isPresentTime(s string) bool {
+	for _, layout := range []string{"15:04 2 Jan 2006", "2 Jan 2006"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+var presentLinkRegexp = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+
+// presentStyle renders the links and fonts of present text
+func presentStyle(text string) string {
+	buf := &strings.Builder{}
+	last := 0
+	for _, m := range presentLinkRegexp.FindAllStringSubmatchIndex(text, -1) {
+		buf.WriteString(presentFonts(text[last:m[0]]))
+		label := ""
+		if m[4] >= 0 {
+			label = text[m[4]:m[5]]
+		}
+		buf.WriteString(presentLink(text[m[2]:m[3]], label))
+		last = m[1]
+	}
+	buf.WriteString(presentFonts(text[last:]))
+	return buf.String()
+}
+
+// presentLink renders a link, which shows the URL without scheme if there is
+// no label
+func presentLink(url, label string) string {
+	text := template.HTMLEscapeString(label)
+	if label == "" {
+		text = template.HTMLEscapeString(strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"))
+	} else {
+		text = presentFonts(label)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, template.HTMLEscapeString(url), text)
+}
+
+// presentFonts renders words in _italic_, *bold* and `code`. Markers inside
+// italic and bold words are spaces, like in _hello_world_.
+func presentFonts(text string) string {
+	buf := &strings.Builder{}
+	last := 0
+	for _, m := range presentCodeRegexp.FindAllStringIndex(text, -1) {
+		buf.WriteString(presentWords(text[last:m[0]]))
+		fmt.Fprintf(buf, "<code>%s</code>", template.HTMLEscapeString(text[m[0]+1:m[1]-1]))
+		last = m[1]
+	}
+	buf.WriteString(presentWords(text[last:]))
+	return buf.String()
+}
+
+var presentCodeRegexp = regexp.MustCompile("`[^`]+`")
+
+func presentWords(text string) string {
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		words[i] = presentFont(word)
+	}
+	return strings.Join(words, " ")
+}
+
+var presentFontTags = map[byte]string{'_': "i", '*': "b"}
+
+func presentFont(word string) string {
+	tag, exists := "", false
+	if len(word) > 0 {
+		tag, exists = presentFontTags[word[0]]
+	}
+	end := len(word)
+	for end > 2 && strings.IndexByte(".,;:!?)'\"", word[end-1]) >= 0 {
+		end--
+	}
+	if !exists || end < 3 || word[end-1] != word[0] {
+		return template.HTMLEscapeString(word)
+	}
+	inner := strings.Replace(word[1:end-1], word[:1], " ", -1)
+	return fmt.Sprintf("<%s>%s</%s>%s", tag, template.HTMLEscapeString(inner), tag, template.HTMLEscapeString(word[end:]))
+}
+
+// presentCommand renders a line starting with a command like .code
+func presentCommand(ctx *SlideContext, line string) (string, error) {
+	fields := strings.Fields(line)
+	args := fields[1:]
+	rest := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	need := func(n int) error {
+		if len(args) < n {
+			return fmt.Errorf("%s needs %d arguments, got %q", fields[0], n, line)
+		}
+		return nil
+	}
+	switch fields[0] {
+	case ".code", ".play":
+		return presentCode(ctx, fields[0], args)
+	case ".image":
+		if err := need(1); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<img src=\"%s\"%s>\n", template.HTMLEscapeString(args[0]), presentSize(args[1:])), nil
+	case ".iframe":
+		if err := need(1); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<iframe src=\"%s\"%s></iframe>\n", template.HTMLEscapeString(args[0]), presentSize(args[1:])), nil
+	case ".link":
+		if err := need(1); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<p class=\"link\">%s</p>\n", presentLink(args[0], strings.TrimSpace(strings.TrimPrefix(rest, args[0])))), nil
+	case ".caption":
+		return fmt.Sprintf("<p class=\"caption\">%s</p>\n", presentStyle(rest)), nil
+	case ".html":
+		if err := need(1); err != nil {
+			return "", err
+		}
+		buf, err := ioutil.ReadFile(ctx.resolvePath(args[0]))
+		return string(buf), err
+	case ".background":
+		// The background is part of the front matter
+		return "", nil
+	}
+	return "", fmt.Errorf("Unknown present command %s", fields[0])
+}
+
+// presentSize returns the height and width attributes of ".image file height
+// width", where _ keeps the size of the image
+func presentSize(args []string) string {
+	attrs := ""
+	for i, name := range []string{"height", "width"} {
+		if i < len(args) && args[i] != "_" {
+			attrs += fmt.Sprintf(" %s=\"%s\"", name, template.HTMLEscapeString(args[i]))
+		}
+	}
+	return attrs
+}
+
+// presentHighlightRegexp matches the comments marking lines to highlight
+var presentHighlightRegexp = regexp.MustCompile(`\s*//\s*HL(\w*)\s*$`)
+
+// presentCode renders ".code [-numbers] [-edit] file [address] [HLname]".
+// Lines ending with OMIT are left out, lines with a "// HLname" comment are
+// highlighted if HLname is given.
+func presentCode(ctx *SlideContext, command string, args []string) (string, error) {
+	numbers := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		numbers = numbers || args[0] == "-numbers"
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("%s needs a file", command)
+	}
+	file := args[0]
+	args = args[1:]
+	highlight := ""
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "HL") {
+		highlight = strings.TrimPrefix(args[len(args)-1], "HL")
+		args = args[:len(args)-1]
+	}
+
+	buf, err := ioutil.ReadFile(ctx.resolvePath(file))
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	start, end := 1, len(lines)
+	if len(args) > 0 {
+		if start, end, err = presentAddress(lines, strings.Join(args, " ")); err != nil {
+			return "", fmt.Errorf("%s %s: %s", command, file, err)
+		}
+	}
+
+	var code, highlighted []string
+	for n := start; n <= end; n++ {
+		line := lines[n-1]
+		if strings.HasSuffix(line, "OMIT") {
+			continue
+		}
+		if m := presentHighlightRegexp.FindStringSubmatchIndex(line); m != nil {
+			if highlight != "" && line[m[2]:m[3]] == highlight {
+				highlighted = append(highlighted, strconv.Itoa(start+len(code)))
+			}
+			line = line[:m[0]]
+		}
+		code = append(code, line)
+	}
+	lang := strings.TrimPrefix(filepath.Ext(file), ".")
+	out, err := highlightCode(&ctx.Presentation, strings.Join(code, "\n")+"\n", lang, strings.Join(highlighted, ","), start, numbers)
+	return string(out), err
+}
+
+// presentAddress returns the first and last line selected by the address of
+// a .code command, which is a line number, /regexp/ or $ for the last line,
+// or a range of two of those separated by a comma
+func presentAddress(lines []string, address string) (int, int, error) {
+	parts := []string{}
+	inRegexp, last := false, 0
+	for i := 0; i < len(address); i++ {
+		switch address[i] {
+		case '\\':
+			i++
+		case '/':
+			inRegexp = !inRegexp
+		case ',':
+			if !inRegexp {
+				parts = append(parts, address[last:i])
+				last = i + 1
+			}
+		}
+	}
+	parts = append(parts, address[last:])
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("Invalid address %q", address)
+	}
+	start, err := addressLine(lines, strings.TrimSpace(parts[0]), 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	end := start
+	if len(parts) == 2 {
+		if end, err = addressLine(lines, strings.TrimSpace(parts[1]), start+1); err != nil {
+			return 0, 0, err
+		}
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("Address %q ends before it starts", address)
+	}
+	return start, end, nil
+}
+
+// addressLine returns the line of a single address, regular expressions are
+// matched from the line from on
+func addressLine(lines []string, address string, from int) (int, error) {
+	if address == "$" {
+		return len(lines), nil
+	}
+	if n, err := strconv.Atoi(address); err == nil {
+		if n < 1 || n > len(lines) {
+			return 0, fmt.Errorf("Line %d is out of bounds, the file has %d lines", n, len(lines))
+		}
+		return n, nil
+	}
+	if len(address) < 2 || address[0] != '/' || address[len(address)-1] != '/' {
+		return 0, fmt.Errorf("Invalid address %q", address)
+	}
+	re, err := regexp.Compile(strings.Replace(address[1:len(address)-1], `\/`, "/", -1))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid address %q: %s", address, err)
+	}
+	for n := from; n <= len(lines); n++ {
+		if re.MatchString(lines[n-1]) {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("No line matches %s", address)
+}
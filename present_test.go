@@ -0,0 +1,126 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const presentTalk = `Go Concurrency
+Patterns for everyone
+15:04 2 Jan 2006
+Tags: go, concurrency
+
+Jane Doe
+Gopher, Example Inc.
+jane@example.com
+https://example.com/jane
+@janedoe
+
+* Goroutines
+
+Run _functions_concurrently_ with *go*, see [[https://go.dev/tour][the tour]] or [[https://go.dev]].
+
+- cheap
+- ` + "`go f()`" + `
+
+: Mention the scheduler
+: and the stacks
+
+* Code
+
+.code hello.go /^func main/,/^}/ HLgo
+.image gopher.png 200 _
+.background bg.png
+
+** Output
+
+  $ go run hello.go
+  hello
+.link https://go.dev/play The playground
+`
+
+const presentSource = `package main
+
+import "fmt" // OMIT
+
+func main() {
+	go fmt.Println("hello") // HLgo
+	select {}
+}
+`
+
+func TestPresentSlides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-present")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	talkPath := filepath.Join(dir, "talk.slide")
+	require.NoError(t, ioutil.WriteFile(talkPath, []byte(presentTalk), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hello.go"), []byte(presentSource), 0644))
+
+	slides, err := DefaultSlidePipeline().RunAll(&Presentation{}, talkPath)
+	require.NoError(t, err)
+	require.Len(t, slides, 3)
+	assert.Equal(t, "Go Concurrency", slides[0].Title)
+	assert.Equal(t, `<h1>Go Concurrency</h1>
+<h2>Patterns for everyone</h2>
+<p class="date">15:04 2 Jan 2006</p>
+<div class="author">
+<p>Jane Doe</p>
+<p>Gopher, Example Inc.</p>
+<p><a href="mailto:jane@example.com">jane@example.com</a></p>
+<p><a href="https://example.com/jane">example.com/jane</a></p>
+<p><a href="https://twitter.com/janedoe">@janedoe</a></p>
+</div>
+`, string(slides[0].Content))
+
+	assert.Equal(t, "talk-2", slides[1].SectionID)
+	assert.Equal(t, "Goroutines", slides[1].Title)
+	assert.Equal(t, `<h2>Goroutines</h2>
+<p>Run <i>functions concurrently</i> with <b>go</b>, see <a href="https://go.dev/tour">the tour</a> or <a href="https://go.dev">go.dev</a>.</p>
+<ul>
+<li>cheap</li>
+<li><code>go f()</code></li>
+</ul>
+`, string(slides[1].Content))
+	assert.Equal(t, "<p>Mention the scheduler\nand the stacks</p>\n", string(slides[1].Notes))
+
+	code := string(slides[2].Content)
+	assert.Equal(t, "bg.png", slides[2].Background.Image)
+	assert.Contains(t, code, `<pre class="chroma"><code class="language-go"><span class="line"><span class="cl"><span class="kd">func</span>`)
+	assert.Contains(t, code, `<span class="line hl"><span class="cl">	<span class="k">go</span>`)
+	assert.NotContains(t, code, "OMIT")
+	assert.NotContains(t, code, "HLgo")
+	assert.Contains(t, code, "<img src=\"gopher.png\" height=\"200\">\n<h3>Output</h3>\n<pre><code>$ go run hello.go\nhello\n</code></pre>\n")
+	assert.Contains(t, code, `<p class="link"><a href="https://go.dev/play">The playground</a></p>`)
+
+	ctx := &SlideContext{Slide: Slide{SourceFile: talkPath}}
+	for src, expected := range map[string]string{
+		"* Broken\n.code hello.go /^func nope/": ".code hello.go: No line matches /^func nope/",
+		"* Broken\n.code hello.go 3,20":         ".code hello.go: Line 20 is out of bounds, the file has 8 lines",
+		"* Broken\n.video talk.mp4":             "Unknown present command .video",
+	} {
+		_, err := (&PresentSlideParser{}).ParseSlide(ctx, []byte(src))
+		assert.EqualError(t, err, expected, src)
+	}
+}
+
+func TestPresentAddress(t *testing.T) {
+	lines := []string{"a", "func f() {", "}", "func g() {", "}"}
+	for address, expected := range map[string][2]int{
+		"2":              {2, 2},
+		"2,$":            {2, 5},
+		"/func g/":       {4, 4},
+		"/^func f/,/^}/": {2, 3},
+		`/\/x|g/,/^}/`:   {4, 5},
+		"/func/,/[,}]/":  {2, 3},
+	} {
+		start, end, err := presentAddress(lines, address)
+		require.NoError(t, err, address)
+		assert.Equal(t, expected, [2]int{start, end}, address)
+	}
+}
@@ -0,0 +1,187 @@
+package showandtell
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// The presenter view embeds the deck twice, once for the current and once for
+// the upcoming slide. Every navigation of the presenter is published on the
+// message bus, so audience clients of the deck can follow along.
+var presenterTmpl = `
+<!doctype html>
+<html>
+	<head>
+		<meta charset="utf-8">
+		<title>[[ .Name ]] - Presenter</title>
+		<style>
+			html, body {
+				margin: 0;
+				height: 100%;
+			}
+			body {
+				box-sizing: border-box;
+				display: grid;
+				grid-template-columns: 3fr 2fr;
+				grid-template-rows: auto 2fr 3fr;
+				grid-template-areas: "timer timer" "current next" "current notes";
+				grid-gap: 8px;
+				padding: 8px;
+				background: #222;
+				color: #eee;
+				font-family: sans-serif;
+			}
+			iframe {
+				width: 100%;
+				height: 100%;
+				border: 1px solid #555;
+				background: #fff;
+			}
+			#timer {
+				grid-area: timer;
+				display: flex;
+				align-items: center;
+				font-size: 1.5em;
+			}
+			#timer > * {
+				margin-right: 1em;
+			}
+			#elapsed {
+				font-family: monospace;
+				font-size: 1.5em;
+			}
+			#current {
+				grid-area: current;
+			}
+			#next {
+				grid-area: next;
+			}
+			#notes {
+				grid-area: notes;
+				overflow: auto;
+				font-size: 1.25em;
+				line-height: 1.4;
+			}
+		</style>
+	</head>
+	<body>
+		<div id="timer">
+			<span id="elapsed">00:00:00</span>
+			<span id="clock"></span>
+			<button id="reset">Reset timer</button>
+		</div>
+		<iframe id="current" src="/"></iframe>
+		<iframe id="next" src="/"></iframe>
+		<div id="notes"></div>
+		<script>
+		(function() {
+			var current = document.getElementById("current");
+			var next = document.getElementById("next");
+			var notes = document.getElementById("notes");
+			var conn;
+
+			function publishState() {
+				var deck = current.contentWindow.Reveal;
+				if (!conn || conn.readyState !== WebSocket.OPEN || !deck || !deck.isReady()) {
+					return;
+				}
+				conn.send(JSON.stringify({type: "publish", topic: "/presenter/state", value: deck.getState()}));
+			}
+
+			function update() {
+				var deck = current.contentWindow.Reveal;
+				if (!deck || !deck.isReady()) {
+					return;
+				}
+				notes.innerHTML = deck.getSlideNotes() || "";
+				var preview = next.contentWindow.Reveal;
+				if (preview && preview.isReady()) {
+					preview.setState(deck.getState());
+					preview.next();
+				}
+				publishState();
+			}
+
+			function onDeckReady(frame, callback) {
+				// Live reload reloads the frames, so this fires after every change
+				frame.addEventListener("load", function() {
+					var deck = frame.contentWindow.Reveal;
+					if (deck.isReady()) {
+						callback(deck);
+					} else {
+						deck.addEventListener("ready", function() { callback(deck); });
+					}
+				});
+			}
+
+			onDeckReady(current, function(deck) {
+				["slidechanged", "fragmentshown", "fragmenthidden", "overviewshown", "overviewhidden"].forEach(function(evt) {
+					deck.addEventListener(evt, update);
+				});
+				current.contentWindow.focus();
+				update();
+			});
+			onDeckReady(next, function(deck) {
+				deck.configure({controls: false, progress: false, keyboard: false});
+				update();
+			});
+
+			function connect() {
+				var url = window.location.host+"/messagebus";
+				if(window.location.protocol === "http:") {
+					url = "ws://"+url;
+				} else {
+					url = "wss://"+url;
+				}
+				conn = new WebSocket(url);
+				conn.onopen = function() {
+					// Audience clients ask for the current state when they connect
+					conn.send(JSON.stringify({type: "subscribe", topic: "/presenter/sync"}));
+					publishState();
+				};
+				conn.onmessage = function(evt) {
+					var msg = JSON.parse(evt.data);
+					if (msg.type === "message" && msg.topic === "/presenter/sync") {
+						publishState();
+					}
+				};
+				conn.onclose = function() {
+					setTimeout(connect, 2000);
+				};
+			}
+			connect();
+
+			var start = Date.now();
+			function pad(n) {
+				return n < 10 ? "0" + n : "" + n;
+			}
+			function tick() {
+				var seconds = Math.floor((Date.now() - start) / 1000);
+				document.getElementById("elapsed").textContent = pad(Math.floor(seconds / 3600)) + ":" +
+					pad(Math.floor(seconds / 60) % 60) + ":" + pad(seconds % 60);
+				document.getElementById("clock").textContent = new Date().toLocaleTimeString();
+			}
+			setInterval(tick, 1000);
+			tick();
+			document.getElementById("reset").addEventListener("click", function() {
+				start = Date.now();
+				tick();
+				current.contentWindow.focus();
+			});
+		})();
+		</script>
+	</body>
+</html>
+`
+
+// RenderPresenter renders the presenter view, showing the current and next
+// slide, the speaker notes and a timer.
+func RenderPresenter(pres *Presentation) ([]byte, error) {
+	tmpl, err := template.New("presenter").Delims("[[", "]]").Parse(presenterTmpl)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, pres)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,111 @@
+package showandtell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile selects the slides of a presentation for an audience. Slides
+// without tags are always included, tagged slides only if they have at least
+// one of the tags of the profile. A profile without tags includes all tagged
+// slides. Drafts are left out unless Drafts is set.
+//
+//	profiles:
+//	  customer:
+//	    tags: [customer]
+//	  internal:
+//	    tags: [internal, partner]
+//	    drafts: true
+type Profile struct {
+	Tags   []string `yaml:"tags"`
+	Drafts bool     `yaml:"drafts"`
+}
+
+// ProfileSelection is the profile and additional tags selected to render a
+// presentation, e.g. on the command line
+type ProfileSelection struct {
+	// Profile is the name of a profile in the presentation config
+	Profile string
+	// Tags are added to the tags of the profile
+	Tags []string
+	// Drafts includes drafts, regardless of the profile
+	Drafts bool
+}
+
+// ActiveProfile returns the profile selected by p.Selection. Without a
+// selection all slides except drafts are included.
+func (p *Presentation) ActiveProfile() (*Profile, error) {
+	profile := &Profile{}
+	sel := p.Selection
+	if sel == nil {
+		return profile, nil
+	}
+	if sel.Profile != "" {
+		named, exists := p.Profiles[sel.Profile]
+		if !exists {
+			return nil, fmt.Errorf("Unknown profile %s, available are %s", sel.Profile, strings.Join(p.profileNames(), ", "))
+		}
+		if named != nil {
+			profile.Tags = append(profile.Tags, named.Tags...)
+			profile.Drafts = named.Drafts
+		}
+	}
+	profile.Tags = append(profile.Tags, sel.Tags...)
+	profile.Drafts = profile.Drafts || sel.Drafts
+	return profile, nil
+}
+
+func (p *Presentation) profileNames() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Includes returns true if the profile includes the slide
+func (p *Profile) Includes(s *Slide) bool {
+	if s.Draft && !p.Drafts {
+		return false
+	}
+	if len(s.Tags) == 0 || len(p.Tags) == 0 {
+		return true
+	}
+	for _, tag := range s.Tags {
+		for _, selected := range p.Tags {
+			if tag == selected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Slide) hasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSlides returns the slides included by the profile. Chapters and
+// stacks are kept as long as any of their slides is included.
+func (p *Profile) filterSlides(slides []*Slide) []*Slide {
+	filtered := make([]*Slide, 0, len(slides))
+	for _, s := range slides {
+		if len(s.SubSlides) > 0 {
+			s.SubSlides = p.filterSlides(s.SubSlides)
+			if len(s.SubSlides) == 0 {
+				continue
+			}
+		} else if !p.Includes(s) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
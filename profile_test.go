@@ -0,0 +1,74 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlidesWithProfile(t *testing.T) {
+	slideDir, err := ioutil.TempDir("", "sat-profile")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(slideDir, "02_internal"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(slideDir, "03_mixed"), 0755))
+
+	files := map[string]string{
+		"01_all.md":                 "# All",
+		"01_draft.md":               "---\ndraft: true\n---\n# Draft",
+		"02_internal/_chapter.yaml": "tags: [internal]\n",
+		"02_internal/01_numbers.md": "# Numbers",
+		"02_internal/02_partner.md": "---\ntags: [partner]\n---\n# Partner",
+		"03_mixed/01_customer.md":   "---\ntags: [customer]\n---\n# Customer",
+		"03_mixed/02_everyone.md":   "# Everyone",
+		"04_stack.md":               "---\ntags: [internal]\n---\n# A\n--\n# B\n",
+		"05_customer_or_partner.md": "---\ntags: [customer, partner]\n---\n# Customer or partner",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, filepath.FromSlash(name)), []byte(content), 0644))
+	}
+
+	pres := &Presentation{
+		Separators: &SlideSeparators{Vertical: DefaultVerticalSeparator},
+		Profiles: map[string]*Profile{
+			"customer": {Tags: []string{"customer"}},
+			"review":   {Drafts: true},
+		},
+	}
+	ids := func(slides []*Slide) (ids []string) {
+		for _, s := range flattenSlides(slides) {
+			ids = append(ids, s.SectionID)
+		}
+		return ids
+	}
+
+	slides, err := ParseSlides(pres, slideDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01_all", "02_internal-01_numbers", "02_internal-02_partner",
+		"03_mixed-01_customer", "03_mixed-02_everyone", "04_stack-1-1", "04_stack-1-2", "05_customer_or_partner"}, ids(slides))
+
+	pres.Selection = &ProfileSelection{Profile: "customer"}
+	slides, err = ParseSlides(pres, slideDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01_all", "03_mixed-01_customer", "03_mixed-02_everyone", "05_customer_or_partner"}, ids(slides))
+	require.Len(t, slides, 3, "The internal chapter and the internal stack must be dropped")
+	require.Len(t, slides[1].SubSlides, 2)
+
+	pres.Selection = &ProfileSelection{Tags: []string{"partner"}, Drafts: true}
+	slides, err = ParseSlides(pres, slideDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01_all", "01_draft", "02_internal-02_partner", "03_mixed-02_everyone", "05_customer_or_partner"}, ids(slides))
+
+	pres.Selection = &ProfileSelection{Profile: "review"}
+	slides, err = ParseSlides(pres, slideDir)
+	require.NoError(t, err)
+	assert.Contains(t, ids(slides), "01_draft")
+
+	pres.Selection = &ProfileSelection{Profile: "partner"}
+	_, err = ParseSlides(pres, slideDir)
+	assert.EqualError(t, err, "Unknown profile partner, available are customer, review")
+}
@@ -3,14 +3,10 @@ package showandtell
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
-
-	blackfriday "gopkg.in/russross/blackfriday.v2"
-	"gopkg.in/yaml.v2"
 )
 
 var Version = "undefined"
@@ -19,8 +15,6 @@ var (
 	DefaultTheme = "white"
 )
 
-var frontMatterDelimiter = []byte(`+++`)
-
 var mainTmpl = `[[define "main" ]] [[ template "base" . ]] [[ end ]]`
 
 var baseTmpl = `
@@ -31,6 +25,10 @@ var baseTmpl = `
 		[[ range .Theme ]]
 		<link rel="stylesheet" href="css/theme/[[.]].css">
 		[[ end ]]
+		<style>[[ layoutCSS ]]</style>
+		<style>[[ .HighlightCSS ]]</style>
+		<style>[[ diagramCSS ]]</style>
+		<style>[[ dataCSS ]]</style>
 	</head>
 	<body>
 		<div class="reveal">
@@ -80,6 +78,40 @@ var baseTmpl = `
 			console.log('Exception during connecting to reload:', ex);
 		}
 		</script>
+		<script>
+		// Follow the slide position published by the presenter view. Decks
+		// embedded in the presenter view itself are controlled from there.
+		function tryConnectToPresenter() {
+			var url = window.location.host+"/messagebus";
+			if(window.location.protocol === "http:") {
+				url = "ws://"+url;
+			} else {
+				url = "wss://"+url;
+			}
+			var conn = new WebSocket(url);
+
+			conn.onopen = function(evt) {
+				conn.send(JSON.stringify({type: "subscribe", topic: "/presenter/state"}));
+				// Ask a running presenter view for its current position
+				conn.send(JSON.stringify({type: "publish", topic: "/presenter/sync", value: {}}));
+			};
+
+			conn.onclose = function(evt) {
+				setTimeout(() => tryConnectToPresenter(), 2000);
+			};
+
+			conn.onmessage = function(evt) {
+				var msg = JSON.parse(evt.data);
+				if (msg.type === "message" && msg.topic === "/presenter/state") {
+					Reveal.setState(msg.value);
+				}
+			};
+		}
+
+		if (window["WebSocket"] && window.parent === window) {
+			tryConnectToPresenter();
+		}
+		</script>
 		[[ end ]]
 	</body>
 </html>
@@ -98,10 +130,19 @@ var comboSlide = `
 
 var subSlideTmpl = `
 [[ define "subSlides" ]]
-<section id="[[ .SectionID ]]" class="chapter">
+<section 
+	id="[[ .SectionID ]]"
+	class="chapter[[ range .Classes ]] [[ . ]][[ end ]]"
+	[[ if .Transition ]]data-transition="[[.Transition]]" [[if .TransitionSpeed]]data-transition-speed="[[.TransitionSpeed]]" [[end]][[end]]
+	[[ .Attributes ]]>
 [[ range .SubSlides ]]
 	[[ template "slide" . ]]
 [[ end ]]
+[[ if .HasNotes ]]
+<aside class="notes">
+[[.Notes]]
+</aside>
+[[ end ]]
 </section>
 [[ end ]]
 `
@@ -109,10 +150,11 @@ var subSlideTmpl = `
 var slideTmpl = `
 [[ define "slide" ]]
 <section 
-	class="slide"
+	class="slide[[ range .Classes ]] [[ . ]][[ end ]]"
 	id="[[ .SectionID ]]" 
 	data-has-notes="[[ .HasNotes ]]" 
-	[[ if .Transition ]]data-transition="[[.Transition]]" [[if .TransitionSpeed]]data-transition-speed="[[.TransitionSpeed]]" [[end]][[end]]>
+	[[ if .Transition ]]data-transition="[[.Transition]]" [[if .TransitionSpeed]]data-transition-speed="[[.TransitionSpeed]]" [[end]][[end]]
+	[[ .Attributes ]]>
 [[ .Content ]]
 [[ if .HasNotes ]]
 <aside class="notes">
@@ -188,6 +230,14 @@ func (r *RevealConfiguration) ToJSON() template.JS {
 	return template.JS(s)
 }
 
+// ToJSONWithoutDependencies is like ToJSON, but omits all dependencies. It is
+// used when the dependencies are already part of the document.
+func (r *RevealConfiguration) ToJSONWithoutDependencies() template.JS {
+	c := *r
+	c.Dependencies = []*RevealDependency{}
+	return c.ToJSON()
+}
+
 func DefaultRevealConfig() *RevealConfiguration {
 	return &RevealConfiguration{
 		Controls: Bool(true),
@@ -204,10 +254,6 @@ func DefaultRevealConfig() *RevealConfiguration {
 				RelSrc: "plugin/zoom-js/zoom.js",
 				Async:  true,
 			},
-			{
-				RelSrc: "plugin/highlight/highlight.js",
-				Async:  true,
-			},
 		},
 	}
 }
@@ -217,14 +263,46 @@ func Bool(in bool) *bool {
 }
 
 type Slide struct {
-	Content    template.HTML
-	SourceFile string
-	SubSlides  []*Slide
-	SectionID  string
-
-	Notes           template.HTML `yaml:"notes"`
-	Transition      *string       `yaml:"transition"`
-	TransitionSpeed *string       `yaml:"transitionSpeed"`
+	Content    template.HTML `yaml:"-" toml:"-" json:"-"`
+	SourceFile string        `yaml:"-" toml:"-" json:"-"`
+	SubSlides  []*Slide      `yaml:"-" toml:"-" json:"-"`
+	SectionID  string        `yaml:"-" toml:"-" json:"-"`
+
+	// Title is used instead of the first heading in the table of contents
+	// of handouts
+	Title           string        `yaml:"title" toml:"title" json:"title"`
+	Notes           template.HTML `yaml:"notes" toml:"notes" json:"notes"`
+	Transition      *string       `yaml:"transition" toml:"transition" json:"transition"`
+	TransitionSpeed *string       `yaml:"transitionSpeed" toml:"transitionSpeed" json:"transitionSpeed"`
+	// Layout is the name of the template wrapping the content
+	Layout string                 `yaml:"layout" toml:"layout" json:"layout"`
+	Params map[string]interface{} `yaml:"params" toml:"params" json:"params"`
+
+	Background *SlideBackground `yaml:"background" toml:"background" json:"background"`
+	// State is added as class to the document while the slide is shown
+	State string `yaml:"state" toml:"state" json:"state"`
+	// AutoSlide is the time in milliseconds until advancing to the next slide
+	AutoSlide *uint64 `yaml:"autoSlide" toml:"autoSlide" json:"autoSlide"`
+	// Timing is the time in seconds planned for the slide, used by the
+	// pacing timer of the speaker notes
+	Timing *uint64 `yaml:"timing" toml:"timing" json:"timing"`
+	// Visibility is either hidden or uncounted
+	Visibility  string `yaml:"visibility" toml:"visibility" json:"visibility"`
+	AutoAnimate bool   `yaml:"autoAnimate" toml:"autoAnimate" json:"autoAnimate"`
+	// Data are additional data-* attributes or the file of a data slide
+	Data SlideData `yaml:"data" toml:"data" json:"data"`
+	// Chart renders the data of the slide as chart instead of a table
+	Chart *SlideChart `yaml:"chart" toml:"chart" json:"chart"`
+	// Classes are added to the section of the slide
+	Classes []string `yaml:"classes" toml:"classes" json:"classes"`
+	// Fragments set to lists shows the items of all lists of Markdown
+	// slides one after another
+	Fragments string `yaml:"fragments" toml:"fragments" json:"fragments"`
+
+	// Draft slides are only included by profiles including drafts
+	Draft bool `yaml:"draft" toml:"draft" json:"draft"`
+	// Tags restrict the profiles including the slide, see Profile
+	Tags []string `yaml:"tags" toml:"tags" json:"tags"`
 }
 
 func (s *Slide) HasNotes() bool {
@@ -240,8 +318,28 @@ type Presentation struct {
 	Name         string               `yaml:"name"`
 	Theme        []string             `yaml:"theme"`
 	Description  string               `yaml:"description"`
-	Slides       []*Slide             `json:"-"`
+	Slides       []*Slide             `json:"-" yaml:"-"`
 	RevealConfig *RevealConfiguration `yaml:"reveal_config"`
+	Outline      []*OutlineEntry      `yaml:"outline"`
+	LayoutDir    string               `yaml:"layout_dir"`
+	// Separators split Markdown files into multiple slides if set
+	Separators *SlideSeparators `yaml:"separators"`
+	// LegacyFrontMatter parses front matter delimited by +++ as YAML instead
+	// of TOML, for slides written before other front matter formats were
+	// supported. Without it, only front matter which isn't TOML is YAML.
+	LegacyFrontMatter bool `yaml:"legacy_front_matter"`
+	// Highlight configures the syntax highlighting of code blocks
+	Highlight *HighlightConfig `yaml:"highlight"`
+	// Profiles are named selections of slides, see Profile
+	Profiles map[string]*Profile `yaml:"profiles"`
+	// Selection selects the profile to render, all slides except drafts
+	// are rendered without one
+	Selection *ProfileSelection `yaml:"-"`
+	// BaseDir is the directory of the presentation config, relative paths
+	// in the config are resolved against it
+	BaseDir string `yaml:"-"`
+	// ConfigFile is the path the presentation was parsed from
+	ConfigFile string `yaml:"-"`
 }
 
 type SlideParser interface {
@@ -251,9 +349,11 @@ type SlideParser interface {
 func DefaultRenderer() *template.Template {
 	var err error
 	tmpl := template.New("main")
-	// TODO add func map
+	tmpl.Funcs(layoutFuncs)
+	tmpl.Funcs(DefaultFuncMap())
 	tmpl.Delims("[[", "]]")
-	for _, tmplStr := range []string{mainTmpl, baseTmpl, slideTmpl, subSlideTmpl, comboSlide} {
+	for _, tmplStr := range []string{mainTmpl, baseTmpl, slideTmpl, subSlideTmpl, comboSlide,
+		titleLayoutTmpl, twoColumnLayoutTmpl, imageLeftLayoutTmpl, quoteLayoutTmpl} {
 		tmpl, err = tmpl.Parse(tmplStr)
 		if err != nil {
 			panic(err)
@@ -263,19 +363,6 @@ func DefaultRenderer() *template.Template {
 	return tmpl
 }
 
-func parseFrontMatter(in []byte) (fm []byte, content []byte) {
-	if !bytes.HasPrefix(in, frontMatterDelimiter) {
-		return []byte{}, in
-	}
-
-	parts := bytes.SplitN(in, frontMatterDelimiter, 3)
-	if len(parts) < 3 {
-		return parts[1], []byte{}
-	}
-
-	return parts[1], parts[2]
-}
-
 func generateSectionID(slidePath string) string {
 	extension := filepath.Ext(slidePath)
 	fileName := filepath.Base(slidePath)
@@ -287,63 +374,6 @@ func generateSectionID(slidePath string) string {
 	return id
 }
 
-func parseSlide(pres *Presentation, slidePath string) (s *Slide, err error) {
-	extension := filepath.Ext(slidePath)
-	extension = strings.TrimPrefix(extension, ".")
-	buf, err := ioutil.ReadFile(slidePath)
-	if err != nil {
-		return nil, err
-	}
-
-	frontMatter, body := parseFrontMatter(buf)
-	if err != nil {
-		return nil, err
-	}
-	s = &Slide{}
-
-	if len(frontMatter) > 0 {
-		if err := yaml.Unmarshal(frontMatter, s); err != nil {
-			return nil, err
-		}
-	}
-
-	s.SourceFile = slidePath
-	s.SectionID = generateSectionID(slidePath)
-
-	if s.HasNotes() {
-		// Notes are in Markdown, so we render it to HTML
-		s.Notes = template.HTML(blackfriday.Run([]byte(s.Notes), blackfriday.WithExtensions(
-			mardownExtensions,
-		)))
-	}
-
-	slideCtx := &SlideContext{
-		*s,
-		*pres,
-	}
-
-	tmpl := DefaultRenderer()
-
-	tmpl, err = tmpl.Parse(string(body))
-	if err != nil {
-		return
-	}
-	tmplBuf := &bytes.Buffer{}
-	if err = tmpl.Execute(tmplBuf, slideCtx); err != nil {
-		return
-	}
-
-	if parser, exists := slideParsers[extension]; exists {
-		s.Content, err = parser.ParseSlide(slideCtx, []byte(body))
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err = fmt.Errorf("No matching slide parser for file type: %s", extension)
-	}
-	return
-}
-
 func renderSubSlides(pres *Presentation, slide *Slide) (template.HTML, error) {
 	slideTmpl := DefaultRenderer()
 	buf := &bytes.Buffer{}
@@ -352,43 +382,76 @@ func renderSubSlides(pres *Presentation, slide *Slide) (template.HTML, error) {
 	return template.HTML(buf.String()), err
 }
 
-func parseSlideFolder(pres *Presentation, slideFolder string) (slides []*Slide, err error) {
-	files, err := ioutil.ReadDir(slideFolder)
+// parseSlideFolder parses the slides of a folder. Their front matter uses
+// the settings of the chapter as defaults, which is nil at the top level.
+func parseSlideFolder(pres *Presentation, slideFolder string, outline []*OutlineEntry, chapter *Slide) (slides []*Slide, err error) {
+	entries, err := listSlideEntries(slideFolder, outline)
 	if err != nil {
 		return nil, err
 	}
-	for _, f := range files {
-
-		slidePath := filepath.Join(slideFolder, f.Name())
-		if f.IsDir() {
-			subSlides, err := parseSlideFolder(pres, slidePath)
+	sectionIDs := uniqueSectionIDs{}
+	pipeline := DefaultSlidePipeline()
+	pipeline.Defaults = chapter
+	for _, e := range entries {
+
+		slidePath := e.path
+		if e.isDir {
+			subChapter, err := loadChapter(slidePath, chapter)
+			if err != nil {
+				return nil, err
+			}
+			subSlides, err := parseSlideFolder(pres, slidePath, e.outline, subChapter)
 			if err != nil {
 				return nil, err
 			}
-			id := generateSectionID(slidePath)
+			id := sectionIDs.get(generateSectionID(slidePath))
 			for _, s := range subSlides {
 				s.SectionID = id + "-" + s.SectionID
 			}
-			s := &Slide{
-				SourceFile: slidePath,
-				SubSlides:  subSlides,
-				SectionID:  id,
-			}
+			s := subChapter
+			s.SourceFile = slidePath
+			s.SubSlides = subSlides
+			s.SectionID = id
 			slides = append(slides, s)
 		} else {
 
-			s, err := parseSlide(pres, slidePath)
+			fileSlides, err := pipeline.RunAll(pres, slidePath)
 			if err != nil {
 				return nil, err
 			}
-			slides = append(slides, s)
+			for _, s := range fileSlides {
+				id := sectionIDs.get(s.SectionID)
+				for _, sub := range s.SubSlides {
+					sub.SectionID = id + strings.TrimPrefix(sub.SectionID, s.SectionID)
+				}
+				s.SectionID = id
+				// reveal.js nests sections only two levels deep, so the
+				// stacks of files in chapters become slides of the chapter
+				if chapter != nil && len(s.SubSlides) > 0 {
+					slides = append(slides, s.SubSlides...)
+					continue
+				}
+				slides = append(slides, s)
+			}
 		}
 	}
 	return slides, nil
 }
 
+// ParseSlides parses all slides in slideFolder. The order of the slides is
+// determined by the outline of the presentation, if there is one, otherwise
+// by the directory order.
 func ParseSlides(pres *Presentation, slideFolder string) ([]*Slide, error) {
-	return parseSlideFolder(pres, slideFolder)
+	sweepRenderCaches()
+	profile, err := pres.ActiveProfile()
+	if err != nil {
+		return nil, err
+	}
+	slides, err := parseSlideFolder(pres, slideFolder, pres.Outline, nil)
+	if err != nil {
+		return nil, err
+	}
+	return profile.filterSlides(slides), nil
 }
 
 func RenderIndex(pres *Presentation, slideFolder string) ([]byte, error) {
@@ -399,7 +462,10 @@ func RenderIndex(pres *Presentation, slideFolder string) ([]byte, error) {
 
 	pres.Slides = slides
 
-	tmpl := DefaultRenderer()
+	tmpl, err := NewRenderer(pres)
+	if err != nil {
+		return nil, err
+	}
 	buf := &bytes.Buffer{}
 	err = tmpl.ExecuteTemplate(buf, "main", pres)
 	return buf.Bytes(), err
@@ -411,10 +477,11 @@ func ParsePresentation(presPath string) (*Presentation, error) {
 		return nil, err
 	}
 	pres := &Presentation{}
-	err = yaml.Unmarshal(buf, pres)
-	if err != nil {
+	if err := decodeYAML(presPath, 0, buf, pres); err != nil {
 		return nil, err
 	}
+	pres.BaseDir = filepath.Dir(presPath)
+	pres.ConfigFile = presPath
 	if pres.RevealConfig == nil {
 		// TODO use a nice and sane default configuration
 		pres.RevealConfig = DefaultRevealConfig()
@@ -2,10 +2,14 @@ package showandtell
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 var showOutput = false
@@ -55,3 +59,70 @@ Test`,
 		assert.Equal(t, []byte(data.expectedBody), body)
 	}
 }
+
+func TestParseSlidesWithOutline(t *testing.T) {
+	pres := &Presentation{}
+	err := yaml.Unmarshal([]byte(`
+outline:
+  - path: 03_chapter
+    slides: [02_frontend_markdown.md]
+  - 01_index.md
+  - 01_index.md
+`), pres)
+	require.NoError(t, err)
+
+	slides, err := ParseSlides(pres, "./test_slides")
+	require.NoError(t, err)
+	require.Len(t, slides, 3)
+
+	assert.Equal(t, "03_chapter", slides[0].SectionID)
+	require.Len(t, slides[0].SubSlides, 1)
+	assert.Equal(t, "03_chapter-02_frontend_markdown", slides[0].SubSlides[0].SectionID)
+	assert.Equal(t, "01_index", slides[1].SectionID)
+	assert.Equal(t, "01_index-2", slides[2].SectionID)
+}
+
+func TestParseSlidesWithInvalidOutline(t *testing.T) {
+	pres := &Presentation{
+		Outline: []*OutlineEntry{{Path: "does_not_exist.md"}},
+	}
+	_, err := ParseSlides(pres, "./test_slides")
+	assert.Error(t, err)
+
+	pres.Outline = []*OutlineEntry{{Path: "01_index.md", Slides: []*OutlineEntry{{Path: "foo.md"}}}}
+	_, err = ParseSlides(pres, "./test_slides")
+	assert.Error(t, err)
+}
+
+func TestSlideLayouts(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "sat-layouts")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+
+	slideDir := filepath.Join(projectDir, "slides")
+	layoutDir := filepath.Join(projectDir, "layouts")
+	require.NoError(t, os.MkdirAll(slideDir, 0777))
+	require.NoError(t, os.MkdirAll(layoutDir, 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(layoutDir, "boxed.html"),
+		[]byte(`<div class="boxed" data-color="[[ .Params.color ]]">[[ .Content ]]</div>`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_custom.md"),
+		[]byte("+++\nlayout: boxed\nparams:\n  color: red\n+++\nboxed content"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "02_columns.md"),
+		[]byte("+++\nlayout: two-column\n+++\nleft\n\n***\n\nright"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "03_title.md"),
+		[]byte("+++\nlayout: title\n+++\n"), 0644))
+
+	pres := &Presentation{Name: "Layout test", BaseDir: projectDir}
+	slides, err := ParseSlides(pres, slideDir)
+	require.NoError(t, err)
+	require.Len(t, slides, 3)
+
+	assert.Contains(t, string(slides[0].Content), `<div class="boxed" data-color="red"><p>boxed content</p>`)
+	assert.Regexp(t, `(?s)<div class="column">\s*<p>left</p>\s*</div>\s*<div class="column">\s*<p>right</p>`, string(slides[1].Content))
+	assert.Contains(t, string(slides[2].Content), `<h1>Layout test</h1>`)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "04_unknown.md"),
+		[]byte("+++\nlayout: does-not-exist\n+++\n"), 0644))
+	_, err = ParseSlides(pres, slideDir)
+	assert.Error(t, err)
+}
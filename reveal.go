@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -105,3 +106,18 @@ func EmitRevealJS(destDir string) error {
 	}
 	return nil
 }
+
+// FindAsset looks up a file by the path it is served under, e.g.
+// "css/theme/white.css", in the bundled reveal.js and custom files.
+func FindAsset(assetPath string) ([]byte, error) {
+	assetPath = strings.TrimPrefix(path.Clean("/"+assetPath), "/")
+	parts := strings.SplitN(assetPath, "/", 2)
+	if len(parts) == 2 {
+		for _, b := range revealBoxes {
+			if b.Name == parts[0] && b.Has(parts[1]) {
+				return b.Find(parts[1])
+			}
+		}
+	}
+	return nil, fmt.Errorf("Asset %s not found", assetPath)
+}
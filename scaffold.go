@@ -0,0 +1,235 @@
+package showandtell
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// starterTemplate is a set of files to start a new presentation with
+type starterTemplate struct {
+	Description string
+	// Files maps paths relative to the presentation dir to their content.
+	// The presentation.yaml is expanded with fmt.Sprintf and the quoted
+	// presentation name as only argument.
+	Files map[string]string
+}
+
+// DefaultStarterTemplate is used by InitPresentation if no template is given
+var DefaultStarterTemplate = "default"
+
+var presentationYAMLTmpl = `# The name is shown as title in the browser and on the title slide
+name: %[1]s
+description: ""
+
+# Themes are loaded from css/theme/, e.g. white, black, league, beige, sky,
+# night, serif, simple or solarized. Own themes can be added to css/theme/.
+theme:
+  - white
+
+# The order of the slides, by default all files in the slides folder are
+# used in alphabetical order.
+# outline:
+#   - 01_title.md
+#   - path: 02_chapter
+#     slides:
+#       - 01_intro.md
+#       - 02_details.md
+
+# Front matter of slides is YAML between ---, TOML between +++ or a JSON
+# object. Slides written for older versions use YAML between +++ as well.
+# legacy_front_matter: true
+
+# Split Markdown files into multiple slides on lines matching these regular
+# expressions, to write a whole talk in one file.
+# separators:
+#   horizontal: "^---$"
+#   vertical: "^--$"
+#   notes: "^Note:"
+
+# Code blocks are highlighted with this chroma style when rendering. Line
+# numbers are shown in all code blocks with line_numbers, and in fenced code
+# highlighting lines like ` + "```go [1-3|5]" + ` anyway.
+# highlight:
+#   style: monokai
+#   line_numbers: false
+
+# Profiles select the slides for an audience with "sat render --profile".
+# Slides with tags in their front matter are only included by profiles with
+# one of their tags, slides with "draft: true" only by profiles with drafts.
+# profiles:
+#   customer:
+#     tags: [customer]
+#   internal:
+#     tags: [internal, partner]
+#     drafts: true
+
+# Layouts in this folder can be selected with "layout" in the front matter of
+# a slide, in addition to the built-in title, two-column, image-left and quote.
+# layout_dir: layouts
+
+# The reveal.js configuration, see https://github.com/hakimel/reveal.js#configuration
+# Without it a sensible default is used. Note that the dependencies replace the
+# default plugins (notes and zoom) once a configuration is given.
+# reveal_config:
+#   controls: true
+#   controlslayout: bottom-right
+#   progress: true
+#   slidenumber: true
+#   hash: true
+#   history: true
+#   center: true
+#   # none/fade/slide/convex/concave/zoom
+#   transition: slide
+#   # default/fast/slow
+#   transitionspeed: default
+#   dependencies:
+#     - relsrc: plugin/notes/notes.js
+#       async: true
+#     - relsrc: plugin/zoom-js/zoom.js
+#       async: true
+`
+
+var titleSlide = `---
+layout: title
+notes: The title slide shows the name and description of the presentation.
+---
+`
+
+var chapterIntroSlide = `# A chapter
+
+Every folder in slides is a chapter, its slides are navigated vertically.
+`
+
+var chapterDetailsSlide = `---
+notes: |
+  Speaker notes are written in Markdown and shown in the presenter view
+  at /presenter when serving the presentation.
+---
+## Details
+
+* Slides are written in Markdown or HTML
+* Front matter between --- configures a slide
+`
+
+var starterTemplates = map[string]*starterTemplate{
+	"default": {
+		Description: "A title slide and an example chapter",
+		Files: map[string]string{
+			"presentation.yaml":               presentationYAMLTmpl,
+			"slides/01_title.md":              titleSlide,
+			"slides/02_chapter/01_intro.md":   chapterIntroSlide,
+			"slides/02_chapter/02_details.md": chapterDetailsSlide,
+		},
+	},
+	"workshop": {
+		Description: "An agenda, exercises with code and a custom layout",
+		Files: map[string]string{
+			"presentation.yaml":               presentationYAMLTmpl,
+			"slides/01_title.md":              titleSlide,
+			"slides/02_agenda.md":             "## Agenda\n\n1. Introduction\n2. Exercises\n3. Wrap up\n",
+			"slides/03_chapter/01_intro.md":   chapterIntroSlide,
+			"slides/03_chapter/02_details.md": chapterDetailsSlide,
+			"slides/04_exercise/01_task.md": `---
+layout: exercise
+params:
+  duration: 15 minutes
+---
+## Exercise
+
+Print a greeting:
+
+[[ code "../../examples/hello.go" ]]
+`,
+			"examples/hello.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello")
+}
+`,
+			"slides/05_wrap_up.md": `---
+layout: quote
+params:
+  author: Someone wise
+---
+Thank you!
+`,
+			"layouts/exercise.html": `<div class="layout layout-exercise">
+[[ .Content ]]
+[[ with .Params.duration ]]<p><small>Time: [[ . ]]</small></p>[[ end ]]
+</div>
+`,
+		},
+	},
+}
+
+// StarterTemplates returns the names of the templates InitPresentation
+// accepts, sorted alphabetically.
+func StarterTemplates() []string {
+	names := make([]string, 0, len(starterTemplates))
+	for name := range starterTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StarterTemplateDescription returns the description of a starter template
+func StarterTemplateDescription(name string) string {
+	if t, exists := starterTemplates[name]; exists {
+		return t.Description
+	}
+	return ""
+}
+
+// InitPresentation creates a new presentation in dir from the starter
+// template with the given name. Next to the presentation config and the
+// slides, a custom files folder is created for each directory the bundled
+// reveal.js files are served from. Existing files are never overwritten.
+func InitPresentation(dir, templateName string) error {
+	if templateName == "" {
+		templateName = DefaultStarterTemplate
+	}
+	starter, exists := starterTemplates[templateName]
+	if !exists {
+		return fmt.Errorf("Unknown template %s, available templates are %s",
+			templateName, strings.Join(StarterTemplates(), ", "))
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for relPath := range starter.Files {
+		target := filepath.Join(dir, filepath.FromSlash(relPath))
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s already exists", target)
+		}
+	}
+
+	for _, b := range revealBoxes {
+		if err := os.MkdirAll(filepath.Join(dir, b.Name), 0777); err != nil {
+			return err
+		}
+	}
+	name := strconv.Quote(filepath.Base(absDir))
+	for relPath, content := range starter.Files {
+		target := filepath.Join(dir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return err
+		}
+		if relPath == "presentation.yaml" {
+			content = fmt.Sprintf(content, name)
+		}
+		if err := ioutil.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
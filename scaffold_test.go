@@ -0,0 +1,46 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitPresentation(t *testing.T) {
+	for _, name := range StarterTemplates() {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sat-init")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			require.NoError(t, InitPresentation(dir, name))
+			for _, b := range revealBoxes {
+				assert.DirExists(t, filepath.Join(dir, b.Name))
+			}
+
+			pres, err := ParsePresentation(filepath.Join(dir, "presentation.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Base(dir), pres.Name)
+
+			slides, err := ParseSlides(pres, filepath.Join(dir, "slides"))
+			require.NoError(t, err)
+			assert.Contains(t, string(slides[0].Content), pres.Name)
+
+			assert.Error(t, InitPresentation(dir, name), "existing files must not be overwritten")
+		})
+	}
+}
+
+func TestInitPresentationUnknownTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sat-init")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Error(t, InitPresentation(dir, "does-not-exist"))
+	_, err = os.Stat(filepath.Join(dir, "presentation.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
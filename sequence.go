@@ -0,0 +1,308 @@
+package showandtell
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// SequenceDiagramRenderer renders sequence diagrams written like those of
+// js-sequence-diagrams or the sequence diagrams of PlantUML:
+//
+//	title: Login
+//	participant Browser
+//	participant "Auth Service" as Auth
+//	Browser->Auth: POST /login
+//	Note over Auth: checks the password
+//	Auth-->Browser: session cookie
+//
+// Messages use -> for calls and --> for replies, ->> and -->> draw open
+// arrowheads. Notes are left of, right of or over one or two participants.
+// Texts may contain \n for line breaks.
+type SequenceDiagramRenderer struct{}
+
+func (s *SequenceDiagramRenderer) RenderDiagram(src []byte) ([]byte, error) {
+	d, err := parseSequence(string(src))
+	if err != nil {
+		return nil, err
+	}
+	return d.svg(contentHash(string(src))[:8]), nil
+}
+
+type sequenceDiagram struct {
+	title        string
+	participants []*sequenceParticipant
+	byName       map[string]*sequenceParticipant
+	steps        []*sequenceStep
+}
+
+type sequenceParticipant struct {
+	label  string
+	index  int
+	center float64
+	width  float64
+}
+
+// sequenceStep is either a message or a note
+type sequenceStep struct {
+	from, to *sequenceParticipant
+	text     string
+	dashed   bool
+	open     bool
+	// note is "left", "right" or "over" for notes
+	note string
+}
+
+var (
+	sequenceTitleRegexp       = regexp.MustCompile(`(?i)^title\s*:?\s*(.+)$`)
+	sequenceParticipantRegexp = regexp.MustCompile(`(?i)^(participant|actor)\s+(.+?)(?:\s+as\s+(\S+))?$`)
+	sequenceNoteRegexp        = regexp.MustCompile(`(?i)^note\s+(left of|right of|over)\s+([^:]+?)\s*:\s*(.*)$`)
+	sequenceMessageRegexp     = regexp.MustCompile(`^(.+?)\s*(-->>|->>|-->|->)\s*(.+?)\s*(?::\s*(.*))?$`)
+)
+
+func parseSequence(src string) (*sequenceDiagram, error) {
+	d := &sequenceDiagram{byName: map[string]*sequenceParticipant{}}
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "'") || strings.HasPrefix(line, "//") ||
+			strings.HasPrefix(line, "@startuml") || strings.HasPrefix(line, "@enduml") {
+			continue
+		}
+
+		if m := sequenceParticipantRegexp.FindStringSubmatch(line); m != nil {
+			name, label := unquote(m[2]), unquote(m[2])
+			if m[3] != "" {
+				name = m[3]
+			}
+			d.participant(name).label = sequenceText(label)
+		} else if m := sequenceNoteRegexp.FindStringSubmatch(line); m != nil {
+			step := &sequenceStep{note: strings.Fields(strings.ToLower(m[1]))[0], text: sequenceText(m[3])}
+			names := strings.Split(m[2], ",")
+			if len(names) > 2 || (len(names) > 1 && step.note != "over") {
+				return nil, fmt.Errorf("Line %d: notes can only be over two participants", i+1)
+			}
+			step.from = d.participant(unquote(names[0]))
+			step.to = d.participant(unquote(names[len(names)-1]))
+			d.steps = append(d.steps, step)
+		} else if m := sequenceTitleRegexp.FindStringSubmatch(line); m != nil {
+			d.title = sequenceText(m[1])
+		} else if m := sequenceMessageRegexp.FindStringSubmatch(line); m != nil {
+			d.steps = append(d.steps, &sequenceStep{
+				from:   d.participant(unquote(m[1])),
+				to:     d.participant(unquote(m[3])),
+				text:   sequenceText(m[4]),
+				dashed: strings.HasPrefix(m[2], "--"),
+				open:   strings.HasSuffix(m[2], ">>"),
+			})
+		} else {
+			return nil, fmt.Errorf("Line %d: expected a message, note, participant or title, found %q", i+1, line)
+		}
+	}
+	if len(d.participants) == 0 {
+		return nil, fmt.Errorf("The diagram has no participants")
+	}
+	return d, nil
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func sequenceText(s string) string {
+	return strings.Replace(strings.TrimSpace(s), `\n`, "\n", -1)
+}
+
+func (d *sequenceDiagram) participant(name string) *sequenceParticipant {
+	if p, exists := d.byName[name]; exists {
+		return p
+	}
+	p := &sequenceParticipant{label: name, index: len(d.participants)}
+	d.participants = append(d.participants, p)
+	d.byName[name] = p
+	return p
+}
+
+// Spacing of sequence diagrams in pixels
+const (
+	sequenceMargin     = 10
+	sequenceGap        = 30
+	sequenceBoxPadding = 10
+	sequenceStepGap    = 16
+	sequenceSelfWidth  = 30
+)
+
+func textHeight(s string) float64 {
+	return float64(len(strings.Split(s, "\n")) * diagramLineHeight)
+}
+
+// layout places the participants far enough apart for the texts between
+// them and returns the size of the diagram
+func (d *sequenceDiagram) layout() (width, height float64) {
+	// gaps[i] is the distance between the centers of participant i and i+1
+	gaps := make([]float64, len(d.participants))
+	for i, p := range d.participants {
+		p.width = math.Max(float64(textWidth(p.label)+2*sequenceBoxPadding), 80)
+		if i > 0 {
+			gaps[i-1] = (d.participants[i-1].width+p.width)/2 + sequenceGap
+		}
+	}
+	require := func(from, to int, distance float64) {
+		current := 0.0
+		for i := from; i < to; i++ {
+			current += gaps[i]
+		}
+		if current < distance {
+			gaps[to-1] += distance - current
+		}
+	}
+	extraRight := 0.0
+	for _, s := range d.steps {
+		from, to := s.from.index, s.to.index
+		if from > to {
+			from, to = to, from
+		}
+		textW := float64(textWidth(s.text)) + 2*sequenceBoxPadding
+		switch {
+		case s.note == "left" && from > 0:
+			require(from-1, from, d.participants[from-1].width/2+textW+sequenceGap)
+		case s.note == "right" && from < len(d.participants)-1:
+			require(from, from+1, d.participants[from+1].width/2+textW+sequenceGap)
+		case s.note == "right", s.note == "" && from == to:
+			if from == len(d.participants)-1 {
+				extraRight = math.Max(extraRight, textW+sequenceSelfWidth)
+			} else {
+				require(from, from+1, d.participants[from+1].width/2+textW+sequenceSelfWidth)
+			}
+		case s.note == "" && from != to:
+			require(from, to, textW)
+		}
+	}
+
+	left := d.participants[0].width / 2
+	for _, s := range d.steps {
+		if s.note == "left" && s.from.index == 0 {
+			left = math.Max(left, float64(textWidth(s.text))+2*sequenceBoxPadding+sequenceGap/2)
+		}
+	}
+	x := sequenceMargin + left
+	for i, p := range d.participants {
+		p.center = x
+		x += gaps[i]
+	}
+	last := d.participants[len(d.participants)-1]
+	width = last.center + math.Max(last.width/2, extraRight) + sequenceMargin
+	width = math.Max(width, float64(textWidth(d.title)+2*sequenceMargin))
+
+	height = sequenceMargin + d.titleHeight() + 2*d.boxHeight() + sequenceStepGap + sequenceMargin
+	for _, s := range d.steps {
+		height += d.stepHeight(s)
+	}
+	return width, height
+}
+
+func (d *sequenceDiagram) titleHeight() float64 {
+	if d.title == "" {
+		return 0
+	}
+	return textHeight(d.title) + sequenceStepGap
+}
+
+func (d *sequenceDiagram) boxHeight() float64 {
+	height := 0.0
+	for _, p := range d.participants {
+		height = math.Max(height, textHeight(p.label))
+	}
+	return height + 2*sequenceBoxPadding
+}
+
+func (d *sequenceDiagram) stepHeight(s *sequenceStep) float64 {
+	h := textHeight(s.text) + sequenceStepGap
+	if s.note != "" {
+		h += sequenceBoxPadding
+	} else if s.from == s.to {
+		h += 20
+	}
+	return h
+}
+
+func (d *sequenceDiagram) svg(id string) []byte {
+	width, height := d.layout()
+	b := &svgBuilder{id: id}
+	b.start(int(math.Ceil(width)), int(math.Ceil(height)))
+
+	y := float64(sequenceMargin)
+	if d.title != "" {
+		b.text(int(width/2), int(y+textHeight(d.title)/2), d.title, "middle", "currentColor")
+		y += d.titleHeight()
+	}
+	boxHeight := d.boxHeight()
+	top := y
+	bottom := height - sequenceMargin - boxHeight
+	for _, p := range d.participants {
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="currentColor" stroke-dasharray="4 4"/>`,
+			p.center, top+boxHeight, p.center, bottom)
+		for _, boxY := range []float64{top, bottom} {
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="currentColor"/>`,
+				p.center-p.width/2, boxY, p.width, boxHeight)
+			b.text(int(p.center), int(boxY+boxHeight/2), p.label, "middle", "currentColor")
+		}
+	}
+
+	y = top + boxHeight + sequenceStepGap
+	for _, s := range d.steps {
+		if s.note != "" {
+			d.drawNote(b, s, y)
+		} else {
+			d.drawMessage(b, s, y)
+		}
+		y += d.stepHeight(s)
+	}
+	return b.end()
+}
+
+func (d *sequenceDiagram) drawMessage(b *svgBuilder, s *sequenceStep, y float64) {
+	dash := ""
+	if s.dashed {
+		dash = ` stroke-dasharray="6 4"`
+	}
+	marker := b.marker(s.open, "currentColor")
+	textH := textHeight(s.text)
+	lineY := y + textH
+	if s.from == s.to {
+		x := s.from.center
+		fmt.Fprintf(b, `<path d="M %.1f %.1f H %.1f V %.1f H %.1f" fill="none" stroke="currentColor"%s marker-end="url(#%s)"/>`,
+			x, lineY, x+sequenceSelfWidth, lineY+20, x, dash, marker)
+		b.text(int(x+sequenceSelfWidth+sequenceBoxPadding), int(lineY+10), s.text, "start", "currentColor")
+		return
+	}
+	fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="currentColor"%s marker-end="url(#%s)"/>`,
+		s.from.center, lineY, s.to.center, lineY, dash, marker)
+	if s.text != "" {
+		b.text(int((s.from.center+s.to.center)/2), int(y+textH/2-2), s.text, "middle", "currentColor")
+	}
+}
+
+func (d *sequenceDiagram) drawNote(b *svgBuilder, s *sequenceStep, y float64) {
+	textW := float64(textWidth(s.text)) + 2*sequenceBoxPadding
+	h := textHeight(s.text) + sequenceBoxPadding
+	var x float64
+	switch s.note {
+	case "left":
+		x = s.from.center - sequenceGap/2 - textW
+	case "right":
+		x = s.from.center + sequenceGap/2
+	default:
+		left, right := math.Min(s.from.center, s.to.center), math.Max(s.from.center, s.to.center)
+		if span := right - left + s.from.width; span > textW {
+			textW = span
+		}
+		x = (left+right)/2 - textW/2
+	}
+	fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#fff8c4" stroke="#888"/>`, x, y, textW, h)
+	b.text(int(x+textW/2), int(y+h/2), s.text, "middle", "#222")
+}
@@ -0,0 +1,195 @@
+package showandtell
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// singleFileJSTmpl replaces the "js" block of the base template. Plugins are
+// loaded as plain script tags instead of reveal.js dependencies so they can be
+// inlined together with reveal.js itself. Live reload makes no sense for an
+// exported file and is left out.
+var singleFileJSTmpl = `
+[[ define "js" ]]
+<script src="js/reveal.js"></script>
+[[ with .RevealConfig ]]
+[[ range .Dependencies ]]
+<script src="[[ .RelSrc ]]"></script>
+[[ end ]]
+<script>
+	Reveal.initialize([[ .ToJSONWithoutDependencies ]]);
+</script>
+[[ end ]]
+[[ end ]]
+`
+
+var (
+	stylesheetRegexp = regexp.MustCompile(`<link rel="stylesheet" href="([^"]+)">`)
+	scriptRegexp     = regexp.MustCompile(`<script src="([^"]+)"></script>`)
+	imgRegexp        = regexp.MustCompile(`(<img\s[^>]*?src="|\sdata-background-image=")([^"]+)(")`)
+	cssImportRegexp  = regexp.MustCompile(`@import\s+(?:url\(\s*)?['"]?([^'")\s;]+)['"]?\s*\)?\s*;`)
+	cssURLRegexp     = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+)
+
+// RenderSingleFile renders the presentation into one self-contained HTML
+// document. Stylesheets, theme, reveal.js, its plugins and all images
+// referenced by the slides are inlined, so the result can be opened offline
+// without any accompanying files.
+func RenderSingleFile(pres *Presentation, slideFolder string) ([]byte, error) {
+	slides, err := ParseSlides(pres, slideFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	pres.Slides = slides
+
+	tmpl, err := NewRenderer(pres)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl, err = tmpl.Parse(singleFileJSTmpl); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(buf, "main", pres); err != nil {
+		return nil, err
+	}
+
+	inliner := &assetInliner{slideFolder: slideFolder}
+	out := inliner.inlineHTML(buf.Bytes())
+	return out, inliner.err
+}
+
+// assetInliner replaces references to assets with their content. The first
+// error encountered is kept, as the regexp replace functions can't return one.
+type assetInliner struct {
+	slideFolder string
+	err         error
+}
+
+func (a *assetInliner) fail(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+func (a *assetInliner) inlineHTML(in []byte) []byte {
+	// Images go first, so references in the inlined scripts are left alone
+	out := imgRegexp.ReplaceAllFunc(in, func(match []byte) []byte {
+		parts := imgRegexp.FindSubmatch(match)
+		src := html.UnescapeString(string(parts[2]))
+		if isRemoteRef(src) {
+			return match
+		}
+		data, err := a.findImage(src)
+		if err != nil {
+			a.fail(err)
+			return match
+		}
+		return []byte(string(parts[1]) + dataURI(src, data) + string(parts[3]))
+	})
+
+	out = stylesheetRegexp.ReplaceAllFunc(out, func(match []byte) []byte {
+		href := html.UnescapeString(string(stylesheetRegexp.FindSubmatch(match)[1]))
+		css, err := a.inlineCSS(href)
+		if err != nil {
+			a.fail(err)
+			return match
+		}
+		return []byte("<style>\n" + css + "\n</style>")
+	})
+
+	out = scriptRegexp.ReplaceAllFunc(out, func(match []byte) []byte {
+		src := html.UnescapeString(string(scriptRegexp.FindSubmatch(match)[1]))
+		js, err := FindAsset(src)
+		if err != nil {
+			a.fail(err)
+			return match
+		}
+		// A literal closing tag would end the inline script prematurely
+		js = bytes.Replace(js, []byte("</script"), []byte(`<\/script`), -1)
+		return []byte("<script>\n" + string(js) + "\n</script>")
+	})
+	return out
+}
+
+// inlineCSS returns the stylesheet served under cssPath with all local imports
+// and url() references embedded.
+func (a *assetInliner) inlineCSS(cssPath string) (string, error) {
+	css, err := FindAsset(cssPath)
+	if err != nil {
+		return "", err
+	}
+	baseDir := path.Dir(cssPath)
+
+	out := cssImportRegexp.ReplaceAllStringFunc(string(css), func(match string) string {
+		ref := cssImportRegexp.FindStringSubmatch(match)[1]
+		if isRemoteRef(ref) {
+			return match
+		}
+		imported, err := a.inlineCSS(path.Join(baseDir, ref))
+		if err != nil {
+			a.fail(err)
+			return match
+		}
+		return imported
+	})
+
+	out = cssURLRegexp.ReplaceAllStringFunc(out, func(match string) string {
+		ref := cssURLRegexp.FindStringSubmatch(match)[1]
+		if isRemoteRef(ref) || strings.HasPrefix(ref, "#") {
+			return match
+		}
+		// Strip query strings and fragments used as cache busters in font urls
+		assetPath := path.Join(baseDir, strings.SplitN(strings.SplitN(ref, "?", 2)[0], "#", 2)[0])
+		data, err := FindAsset(assetPath)
+		if err != nil {
+			a.fail(err)
+			return match
+		}
+		return `url("` + dataURI(assetPath, data) + `")`
+	})
+	return out, nil
+}
+
+// findImage resolves an image referenced by a slide. Images are usually served
+// from the custom files, but paths relative to the slide folder are accepted
+// as well.
+func (a *assetInliner) findImage(src string) ([]byte, error) {
+	if data, err := FindAsset(src); err == nil {
+		return data, nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(a.slideFolder, filepath.FromSlash(src)))
+	if err != nil {
+		return nil, fmt.Errorf("Image %s not found", src)
+	}
+	return data, nil
+}
+
+func isRemoteRef(ref string) bool {
+	for _, prefix := range []string{"data:", "http:", "https:", "//"} {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func dataURI(name string, data []byte) string {
+	mimeType := mime.TypeByExtension(path.Ext(name))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	// Parameters like the charset are not needed for base64 encoded data
+	mimeType = strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
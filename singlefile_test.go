@@ -0,0 +1,61 @@
+package showandtell
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSingleFile(t *testing.T) {
+	require.NoError(t, cssBox.AddString("reveal.css", `@import url(../lib/font/test.css); .reveal {}`))
+	require.NoError(t, cssBox.AddString("theme/white.css", `.white {}`))
+	require.NoError(t, libBox.AddString("font/test.css", `@font-face { src: url(test.woff?v=1); }`))
+	require.NoError(t, libBox.AddString("font/test.woff", `woff`))
+	require.NoError(t, jsBox.AddString("reveal.js", `var Reveal = {}; // </script>`))
+	require.NoError(t, pluginBox.AddString("notes/notes.js", `var RevealNotes = {};`))
+	require.NoError(t, imagesBox.AddString("logo.png", "\x89PNG\r\n\x1a\n"))
+
+	slideDir, err := ioutil.TempDir("", "sat-single-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(slideDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_logo.md"), []byte(`![logo](images/logo.png)`), 0644))
+
+	pres := &Presentation{
+		Name:  "foo",
+		Theme: []string{"white"},
+		RevealConfig: &RevealConfiguration{
+			Dependencies: []*RevealDependency{
+				{RelSrc: "plugin/notes/notes.js", Async: true},
+			},
+		},
+	}
+
+	out, err := RenderSingleFile(pres, slideDir)
+	require.NoError(t, err)
+	html := string(out)
+
+	assert.NotContains(t, html, `<link rel="stylesheet"`)
+	assert.NotContains(t, html, `<script src=`)
+	assert.NotContains(t, html, "/livereload")
+	assert.Contains(t, html, `.reveal {}`)
+	assert.Contains(t, html, `.white {}`)
+	assert.Contains(t, html, `url("data:font/woff;base64,d29mZg==")`)
+	assert.Contains(t, html, `var Reveal = {}; // <\/script>`)
+	assert.Contains(t, html, `var RevealNotes = {};`)
+	assert.Contains(t, html, `"dependencies":[]`)
+	assert.Contains(t, html, `src="data:image/png;base64,`)
+}
+
+func TestRenderSingleFileMissingAsset(t *testing.T) {
+	pres := &Presentation{
+		Name:  "foo",
+		Theme: []string{"does-not-exist"},
+	}
+
+	_, err := RenderSingleFile(pres, "./test_slides")
+	assert.Error(t, err)
+}
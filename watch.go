@@ -0,0 +1,281 @@
+package showandtell
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDebounce is the time a Watcher waits for further events before it
+// rerenders. Editors usually emit several events for a single save.
+var DefaultDebounce = 200 * time.Millisecond
+
+// watchChanges records which parts of a presentation changed
+type watchChanges struct {
+	slides bool
+	config bool
+	assets bool
+}
+
+func (c watchChanges) any() bool {
+	return c.slides || c.config || c.assets
+}
+
+func (c watchChanges) merge(other watchChanges) watchChanges {
+	return watchChanges{
+		slides: c.slides || other.slides,
+		config: c.config || other.config,
+		assets: c.assets || other.assets,
+	}
+}
+
+// Watcher watches the slides and their data files, the presentation
+// configuration and the custom files of a PresentationServer and rerenders
+// the presentation when any of them change.
+type Watcher struct {
+	// Debounce is the time to wait for further events before rerendering
+	Debounce time.Duration
+
+	server           *PresentationServer
+	slideDir         string
+	presentationPath string
+	customFileDir    string
+	layoutDir        string
+	// dataFiles are the data files of slides, see SlideData
+	dataFiles map[string]bool
+	fsWatcher *fsnotify.Watcher
+	logger    logrus.FieldLogger
+}
+
+// NewWatcher creates a Watcher for the slides served by server, the
+// presentation config at presentationPath and the custom files in
+// customFileDir. Call Run to start watching.
+func NewWatcher(server *PresentationServer, presentationPath, customFileDir string) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Debounce:  DefaultDebounce,
+		server:    server,
+		dataFiles: map[string]bool{},
+		fsWatcher: fsWatcher,
+		logger:    logrus.WithField("component", "Watcher"),
+	}
+	if w.slideDir, err = filepath.Abs(server.slideDir); err != nil {
+		return nil, err
+	}
+	if w.presentationPath, err = filepath.Abs(presentationPath); err != nil {
+		return nil, err
+	}
+	if w.customFileDir, err = filepath.Abs(customFileDir); err != nil {
+		return nil, err
+	}
+
+	if err := w.addRecursive(w.slideDir); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	// Editors often replace files instead of writing them, so the directory
+	// of the config is watched instead of the file itself
+	if err := fsWatcher.Add(filepath.Dir(w.presentationPath)); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	if err := w.watchLayoutDir(server.pres); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	if dirExists(w.customFileDir) {
+		if err := fsWatcher.Add(w.customFileDir); err != nil {
+			fsWatcher.Close()
+			return nil, err
+		}
+		for _, b := range revealBoxes {
+			dirPath := filepath.Join(w.customFileDir, b.Name)
+			if !dirExists(dirPath) {
+				continue
+			}
+			if err := w.addRecursive(dirPath); err != nil {
+				fsWatcher.Close()
+				return nil, err
+			}
+		}
+	}
+	w.watchDataFiles()
+	return w, nil
+}
+
+// watchLayoutDir watches the layout folder of pres, which moves when
+// layout_dir changes in the config
+func (w *Watcher) watchLayoutDir(pres *Presentation) error {
+	layoutDir, err := filepath.Abs(pres.layoutDir())
+	if err != nil {
+		return err
+	}
+	w.layoutDir = layoutDir
+	if !dirExists(layoutDir) {
+		return nil
+	}
+	return w.addRecursive(layoutDir)
+}
+
+// watchDataFiles watches the data files of the slides last rendered by the
+// server, which may be outside of the slide folder
+func (w *Watcher) watchDataFiles() {
+	w.server.indexLock.Lock()
+	var files []string
+	var collect func(slides []*Slide)
+	collect = func(slides []*Slide) {
+		for _, s := range slides {
+			if file := s.dataFile(); file != "" {
+				files = append(files, file)
+			}
+			collect(s.SubSlides)
+		}
+	}
+	collect(w.server.pres.Slides)
+	w.server.indexLock.Unlock()
+
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil || w.dataFiles[path] {
+			continue
+		}
+		// Like the config, the directory is watched as editors often
+		// replace files
+		if err := w.fsWatcher.Add(filepath.Dir(path)); err != nil {
+			w.logger.WithError(err).WithField("path", path).Warn("Failed to watch data file")
+			continue
+		}
+		w.dataFiles[path] = true
+	}
+}
+
+// addRecursive watches dirPath and all directories below it, as fsnotify
+// doesn't watch recursively.
+func (w *Watcher) addRecursive(dirPath string) error {
+	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return w.fsWatcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func isBelow(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// classify determines what a changed path belongs to
+func (w *Watcher) classify(path string) watchChanges {
+	if path == w.presentationPath {
+		return watchChanges{config: true}
+	}
+	if isBelow(path, w.slideDir) || isBelow(path, w.layoutDir) || w.dataFiles[path] {
+		return watchChanges{slides: true}
+	}
+	for _, b := range revealBoxes {
+		if isBelow(path, filepath.Join(w.customFileDir, b.Name)) {
+			return watchChanges{assets: true}
+		}
+	}
+	return watchChanges{}
+}
+
+func (w *Watcher) handleEvent(evt fsnotify.Event) watchChanges {
+	if evt.Op == fsnotify.Chmod {
+		return watchChanges{}
+	}
+	path, err := filepath.Abs(evt.Name)
+	if err != nil {
+		return watchChanges{}
+	}
+	changes := w.classify(path)
+	if changes.any() && evt.Op&fsnotify.Create == fsnotify.Create {
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			if err := w.addRecursive(path); err != nil {
+				w.logger.WithError(err).WithField("path", path).Warn("Failed to watch new directory")
+			}
+		}
+	}
+	if changes.any() {
+		w.logger.WithFields(logrus.Fields{
+			"path":  path,
+			"event": evt.Op.String(),
+		}).Debug("File changed")
+	}
+	return changes
+}
+
+// apply reloads whatever changed and rerenders the presentation
+func (w *Watcher) apply(changes watchChanges) {
+	if changes.config {
+		w.logger.WithField("path", w.presentationPath).Info("Presentation config changed, reloading")
+		pres, err := ParsePresentation(w.presentationPath)
+		if err != nil {
+			w.logger.WithError(err).Error("Failed to parse presentation config, keeping the previous one")
+		} else {
+			w.server.SetPresentation(pres)
+			if err := w.watchLayoutDir(pres); err != nil {
+				w.logger.WithError(err).Warn("Failed to watch layout folder")
+			}
+		}
+	}
+	if changes.assets {
+		w.logger.WithField("path", w.customFileDir).Info("Custom files changed, reloading")
+		if err := AddCustomFiles(w.customFileDir); err != nil {
+			w.logger.WithError(err).Error("Failed to add custom files")
+		}
+	}
+	w.logger.Info("Rerendering presentation")
+	if err := w.server.Rerender(); err != nil {
+		w.logger.WithError(err).Error("Failed to rerender presentation")
+	}
+	w.watchDataFiles()
+}
+
+// Run processes file system events until ctx is done. Bursts of events are
+// coalesced into a single rerender.
+func (w *Watcher) Run(ctx context.Context) {
+	var pending watchChanges
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			changes := w.handleEvent(evt)
+			if !changes.any() {
+				continue
+			}
+			pending = pending.merge(changes)
+			debounce = time.After(w.Debounce)
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.WithError(err).Warn("Error while watching files")
+		case <-debounce:
+			w.apply(pending)
+			pending = watchChanges{}
+			debounce = nil
+		}
+	}
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.fsWatcher.Close()
+}
@@ -0,0 +1,169 @@
+package showandtell
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	t.Fatal("Timed out waiting for condition")
+}
+
+func TestWatcher(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "sat-watcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+
+	presentationPath := filepath.Join(projectDir, "presentation.yaml")
+	slideDir := filepath.Join(projectDir, "slides")
+	nestedDir := filepath.Join(slideDir, "01_chapter")
+	require.NoError(t, os.MkdirAll(nestedDir, 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "css"), 0777))
+	require.NoError(t, ioutil.WriteFile(presentationPath, []byte("name: watched\n"), 0644))
+	slidePath := filepath.Join(nestedDir, "01_slide.md")
+	require.NoError(t, ioutil.WriteFile(slidePath, []byte("before"), 0644))
+
+	pres, err := ParsePresentation(presentationPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, err := NewPresentationServer(ctx, pres, slideDir, "127.0.0.1:45372")
+	require.NoError(t, err)
+
+	watcher, err := NewWatcher(server, presentationPath, projectDir)
+	require.NoError(t, err)
+	defer watcher.Close()
+	watcher.Debounce = time.Millisecond * 50
+	go watcher.Run(ctx)
+
+	index := func() string {
+		server.indexLock.Lock()
+		defer server.indexLock.Unlock()
+		return string(server.indexBytes)
+	}
+
+	require.NoError(t, ioutil.WriteFile(slidePath, []byte("after"), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), "after") })
+
+	require.NoError(t, ioutil.WriteFile(presentationPath, []byte("name: watched\ntheme: [black]\n"), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), "css/theme/black.css") })
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "css", "watched.css"), []byte(".watched {}"), 0644))
+	waitFor(t, func() bool { return cssBox.Has("watched.css") })
+
+	newDir := filepath.Join(slideDir, "02_chapter")
+	require.NoError(t, os.Mkdir(newDir, 0777))
+	time.Sleep(time.Millisecond * 100)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(newDir, "01_new.md"), []byte("new slide"), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), "new slide") })
+}
+
+func TestWatcherDataFiles(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "sat-watcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+
+	presentationPath := filepath.Join(projectDir, "presentation.yaml")
+	slideDir := filepath.Join(projectDir, "slides")
+	dataDir := filepath.Join(projectDir, "data")
+	require.NoError(t, os.MkdirAll(slideDir, 0777))
+	require.NoError(t, os.MkdirAll(dataDir, 0777))
+	require.NoError(t, ioutil.WriteFile(presentationPath, []byte("name: watched\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_metrics.md"), []byte("---\ndata: ../data/metrics.csv\n---\n"), 0644))
+	dataPath := filepath.Join(dataDir, "metrics.csv")
+	require.NoError(t, ioutil.WriteFile(dataPath, []byte("week,signups\n1,10\n"), 0644))
+
+	pres, err := ParsePresentation(presentationPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, err := NewPresentationServer(ctx, pres, slideDir, "127.0.0.1:45373")
+	require.NoError(t, err)
+
+	watcher, err := NewWatcher(server, presentationPath, projectDir)
+	require.NoError(t, err)
+	defer watcher.Close()
+	watcher.Debounce = time.Millisecond * 50
+	go watcher.Run(ctx)
+
+	index := func() string {
+		server.indexLock.Lock()
+		defer server.indexLock.Unlock()
+		return string(server.indexBytes)
+	}
+	assert.Contains(t, index(), `<td class="number">10</td>`)
+
+	require.NoError(t, ioutil.WriteFile(dataPath, []byte("week,signups\n1,42\n"), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), `<td class="number">42</td>`) })
+}
+
+func TestWatcherLayoutDir(t *testing.T) {
+	projectDir, err := ioutil.TempDir("", "sat-watcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(projectDir)
+
+	presentationPath := filepath.Join(projectDir, "presentation.yaml")
+	slideDir := filepath.Join(projectDir, "slides")
+	themeDir := filepath.Join(projectDir, "themes")
+	require.NoError(t, os.MkdirAll(slideDir, 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "layouts"), 0777))
+	require.NoError(t, os.MkdirAll(themeDir, 0777))
+	require.NoError(t, ioutil.WriteFile(presentationPath, []byte("name: watched\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(slideDir, "01_boxed.md"), []byte("---\nlayout: boxed\n---\nboxed"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "layouts", "boxed.html"), []byte(`<div class="old-box">[[ .Content ]]</div>`), 0644))
+	layoutPath := filepath.Join(themeDir, "boxed.html")
+	require.NoError(t, ioutil.WriteFile(layoutPath, []byte(`<div class="new-box">[[ .Content ]]</div>`), 0644))
+
+	pres, err := ParsePresentation(presentationPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, err := NewPresentationServer(ctx, pres, slideDir, "127.0.0.1:45374")
+	require.NoError(t, err)
+
+	watcher, err := NewWatcher(server, presentationPath, projectDir)
+	require.NoError(t, err)
+	defer watcher.Close()
+	watcher.Debounce = time.Millisecond * 50
+	go watcher.Run(ctx)
+
+	index := func() string {
+		server.indexLock.Lock()
+		defer server.indexLock.Unlock()
+		return string(server.indexBytes)
+	}
+	assert.Contains(t, index(), `class="old-box"`)
+
+	require.NoError(t, ioutil.WriteFile(presentationPath, []byte("name: watched\nlayout_dir: themes\n"), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), `class="new-box"`) })
+
+	require.NoError(t, ioutil.WriteFile(layoutPath, []byte(`<div class="edited-box">[[ .Content ]]</div>`), 0644))
+	waitFor(t, func() bool { return strings.Contains(index(), `class="edited-box"`) })
+}
+
+func TestWatchChangesMerge(t *testing.T) {
+	changes := watchChanges{slides: true}.merge(watchChanges{assets: true})
+	assert.True(t, changes.slides)
+	assert.True(t, changes.assets)
+	assert.False(t, changes.config)
+	assert.False(t, watchChanges{}.any())
+}